@@ -0,0 +1,72 @@
+// Command backfill rebuilds a party's sentiment history from archived news,
+// Reddit search and stored feed items, one snapshot per time bucket.
+//
+//	go run ./cmd/backfill -party DMK -from 2026-01-01 -to 2026-02-01
+//
+// Finished buckets are skipped, so an interrupted run can simply be restarted.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	partyFlag := flag.String("party", "", "party id, name or alias")
+	fromFlag := flag.String("from", "", "start date, YYYY-MM-DD (UTC)")
+	toFlag := flag.String("to", "", "end date, YYYY-MM-DD (UTC, exclusive); defaults to today")
+	bucket := flag.Duration("bucket", 24*time.Hour, "width of each snapshot bucket")
+	force := flag.Bool("force", false, "redo buckets that already finished")
+	flag.Parse()
+
+	if *partyFlag == "" || *fromFlag == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	from, err := time.Parse("2006-01-02", *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid -from: %v", err)
+	}
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if *toFlag != "" {
+		if to, err = time.Parse("2006-01-02", *toFlag); err != nil {
+			log.Fatalf("Invalid -to: %v", err)
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	db.Connect()
+	services.EnsureDefaultTargets()
+	services.EnsureDefaultLeaders()
+
+	party, err := services.ResolveParty(*partyFlag)
+	if err != nil {
+		log.Fatalf("Party %q not found", *partyFlag)
+	}
+
+	// Stop cleanly on Ctrl-C; the current bucket is retried next run
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err = services.Backfill(ctx, party, services.BackfillOptions{
+		From:   from,
+		To:     to,
+		Bucket: *bucket,
+		Force:  *force,
+	})
+	if err != nil {
+		log.Fatalf("Backfill failed: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+// Command backtest checks whether the pulse would have called a past
+// election. It projects the election using only snapshots and polls from
+// before a cutoff, then compares the projection with the actual results.
+//
+//	go run ./cmd/backtest -year 2021 -file tn2021.csv -base-year 2016 -cutoff 2021-04-01
+//
+// The base year's results must be imported (cmd/importresults). The tested
+// year's results are read from -file, or from the database if it is left out.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	year := flag.Int("year", 0, "election year to test, e.g. 2021")
+	file := flag.String("file", "", "CSV with that year's results, as for cmd/importresults; defaults to imported results")
+	baseYear := flag.Int("base-year", 0, "imported election to swing from, e.g. 2016")
+	cutoffFlag := flag.String("cutoff", "", "use data from before this date only, YYYY-MM-DD (UTC)")
+	method := flag.String("method", services.SwingUniform, "swing method, uniform or proportional")
+	runs := flag.Int("runs", 2000, "Monte Carlo runs")
+	seed := flag.Uint64("seed", 1, "random seed")
+	recentDays := flag.Int("recent-days", 7, "days before the cutoff for the current sentiment")
+	baselineDays := flag.Int("baseline-days", 30, "days before that to compare with")
+	asJSON := flag.Bool("json", false, "print the full report as JSON")
+	flag.Parse()
+
+	if *year == 0 || *baseYear == 0 || *cutoffFlag == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	cutoff, err := time.Parse("2006-01-02", *cutoffFlag)
+	if err != nil {
+		log.Fatalf("Invalid -cutoff: %v", err)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	db.Connect()
+
+	var actual *services.ParsedResults
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", *file, err)
+		}
+		actual, err = services.ParseResults(f, *year)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Reading %s failed: %v", *file, err)
+		}
+	} else if actual, err = services.LoadResults(*year); err != nil {
+		log.Fatalf("Loading %d results failed: %v", *year, err)
+	}
+
+	report, err := services.Backtest(services.ProjectionOptions{
+		BaseYear:     *baseYear,
+		Method:       *method,
+		Runs:         *runs,
+		Seed:         *seed,
+		RecentDays:   *recentDays,
+		BaselineDays: *baselineDays,
+		AsOf:         cutoff,
+	}, actual)
+	if err != nil {
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		return
+	}
+	printReport(report)
+}
+
+func printReport(r *services.BacktestReport) {
+	fmt.Printf("%d from %d, data before %s, %s swing of %.2f per point (%s)\n\n",
+		r.Year, r.BaseYear, r.Cutoff.Format("2006-01-02"), r.Method, r.SwingPerPt, r.SwingSource)
+	if c := r.Calibration; c != nil {
+		fmt.Printf("Calibration: share = %.1f + %.2f x score, r2 %.2f, rmse %.1f, %d points from %d polls\n\n",
+			c.Intercept, c.Slope, c.R2, c.RMSE, c.N, c.Polls)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Party\tProjected\tRange\tActual\tError\tPrevious\tShare\tActual share\tCalibrated\t")
+	for _, p := range r.Parties {
+		share, actualShare, calibrated := "-", "-", "-"
+		if p.ProjectedShare != nil {
+			share = fmt.Sprintf("%.1f", *p.ProjectedShare)
+			actualShare = fmt.Sprintf("%.1f", *p.ActualShare)
+		}
+		if p.Calibrated != nil {
+			calibrated = fmt.Sprintf("%.1f (%.1f-%.1f)", p.Calibrated.Share, p.Calibrated.Low, p.Calibrated.High)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d-%d\t%d\t%+d\t%d\t%s\t%s\t%s\t\n",
+			p.Name, p.Projected, p.Low, p.High, p.Actual, p.SeatError, p.Previous, share, actualShare, calibrated)
+	}
+	w.Flush()
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Region\tSeats\tWinners right\tNaive\tBrier\tSeat MAE\tNaive MAE\t")
+	row := func(name string, m services.BacktestMetrics) {
+		fmt.Fprintf(w, "%s\t%d\t%.0f%%\t%.0f%%\t%.3f\t%.1f\t%.1f\t\n",
+			name, m.Seats, 100*m.WinnerAccuracy, 100*m.NaiveAccuracy, m.Brier, m.SeatMAE, m.NaiveSeatMAE)
+	}
+	for _, reg := range r.Regions {
+		row(reg.Region, reg.BacktestMetrics)
+	}
+	row("All", r.Overall)
+	w.Flush()
+
+	for _, warn := range r.Warnings {
+		fmt.Printf("\nWarning: %s", warn)
+	}
+	if len(r.Warnings) > 0 {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,49 @@
+// Command importresults loads a past assembly election's constituency
+// results from CSV, one row per candidate.
+//
+//	go run ./cmd/importresults -year 2021 -file tn2021.csv
+//
+// Re-running with a corrected file replaces that year's results.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	year := flag.Int("year", 0, "election year, e.g. 2021")
+	file := flag.String("file", "", "CSV file with constituency_no, constituency, party and votes columns")
+	flag.Parse()
+
+	if *year == 0 || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open %s: %v", *file, err)
+	}
+	defer f.Close()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	db.Connect()
+
+	summary, err := services.ImportResults(f, *year)
+	if err != nil {
+		log.Fatalf("Import failed: %v", err)
+	}
+	log.Printf("Imported %d rows for %d constituencies (%d)", summary.Rows, summary.Constituencies, summary.Year)
+	if len(summary.Unmatched) > 0 {
+		log.Printf("Counted as Others: %v", summary.Unmatched)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"election-pulse-backend/db"
 	"election-pulse-backend/handlers"
+	"election-pulse-backend/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -19,6 +20,8 @@ func main() {
 
 	// Connect to Database
 	db.Connect()
+	services.EnsureDefaultTargets()
+	services.EnsureDefaultLeaders()
 
 	// Initialize Fiber app
 	app := fiber.New()
@@ -28,8 +28,51 @@ func Connect() {
 	log.Println("Database connected successfully")
 
 	// Auto Migrate
-	err = DB.AutoMigrate(&models.Party{}, &models.SentimentSnapshot{})
+	err = DB.AutoMigrate(
+		&models.Party{},
+		&models.Alliance{},
+		&models.AllianceMembership{},
+		&models.SentimentSnapshot{},
+		&models.Leader{},
+		&models.LeaderSnapshot{},
+		&models.WatchedChannel{},
+		&models.QuotaUsage{},
+		&models.SourceTarget{},
+		&models.Feed{},
+		&models.FeedItem{},
+		&models.BackfillBucket{},
+		&models.Constituency{},
+		&models.ElectionResult{},
+		&models.Poll{},
+		&models.PollShare{},
+	)
 	if err != nil {
 		log.Printf("Failed to auto migrate: %v", err)
 	}
+
+	if err := createViews(); err != nil {
+		log.Printf("Failed to create views: %v", err)
+	}
+}
+
+// createViews sets up the materialized aggregates the read endpoints use.
+// services.RefreshHourlyScores keeps them current.
+func createViews() error {
+	// Hourly buckets in UTC. The unique index lets the view be refreshed
+	// concurrently, so reads never block on a refresh. last_snapshot_at lets
+	// any replica tell whether the view is behind the table.
+	err := DB.Exec(`
+		CREATE MATERIALIZED VIEW IF NOT EXISTS party_hourly_scores AS
+		SELECT party_id,
+		       date_trunc('hour', created_at AT TIME ZONE 'UTC') AS hour,
+		       AVG(score) AS avg_score,
+		       COUNT(*) AS snapshots,
+		       COALESCE(SUM(volume), 0) AS volume,
+		       MAX(created_at) AS last_snapshot_at
+		FROM sentiment_snapshots
+		GROUP BY 1, 2`).Error
+	if err != nil {
+		return err
+	}
+	return DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_party_hourly_scores ON party_hourly_scores (party_id, hour)`).Error
 }
@@ -5,8 +5,11 @@
 CREATE TABLE parties (
     id SERIAL PRIMARY KEY,
     name VARCHAR(255),
+    aliases JSONB DEFAULT '[]', -- Other accepted names, e.g. ["Dravida Munnetra Kazhagam"]
     leader VARCHAR(255),
     color_hex VARCHAR(50),
+    logo_url TEXT,
+    active BOOLEAN DEFAULT TRUE,
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
     deleted_at TIMESTAMPTZ
@@ -14,16 +17,266 @@ CREATE TABLE parties (
 
 CREATE INDEX idx_parties_deleted_at ON parties(deleted_at);
 
+-- Table: alliances
+CREATE TABLE alliances (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    color_hex VARCHAR(50),
+    description TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_alliances_name ON alliances(name);
+
+-- Table: alliance_memberships
+-- until NULL means the membership is current.
+CREATE TABLE alliance_memberships (
+    id SERIAL PRIMARY KEY,
+    alliance_id INTEGER NOT NULL REFERENCES alliances(id),
+    party_id INTEGER NOT NULL REFERENCES parties(id),
+    since TIMESTAMPTZ,
+    until TIMESTAMPTZ,
+    seats INTEGER DEFAULT 0, -- Seats contested for the alliance
+    vote_share DOUBLE PRECISION DEFAULT 0, -- Percent, last election
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_alliance_memberships_alliance_id ON alliance_memberships(alliance_id);
+CREATE INDEX idx_alliance_memberships_party_id ON alliance_memberships(party_id);
+
 -- Table: sentiment_snapshots
 CREATE TABLE sentiment_snapshots (
     id SERIAL PRIMARY KEY,
     party_id INTEGER REFERENCES parties(id),
     score DOUBLE PRECISION,
-    key_issue TEXT,
-    source_breakdown JSONB, -- Stores JSON like {"yt": 0.5, "news": 0.5}
+    key_topics JSONB, -- JSON array of strings
+    emotion VARCHAR(255),
+    fact_check_notes TEXT,
+    source_breakdown JSONB, -- Per-source results and coverage of the run
+    inorganic_share DOUBLE PRECISION DEFAULT 0, -- Share of social items flagged as coordinated, 0-1
+    degraded BOOLEAN DEFAULT FALSE, -- Below the coverage thresholds
+    backfilled BOOLEAN DEFAULT FALSE, -- Rebuilt from archives by cmd/backfill
+    volume INTEGER DEFAULT 0, -- News and social items behind the score
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Materialized view: party_hourly_scores
+-- Hourly score and volume per party behind /leaderboard. Refreshed by the
+-- backend when the snapshot count or newest created_at no longer matches.
+CREATE MATERIALIZED VIEW party_hourly_scores AS
+SELECT party_id,
+       date_trunc('hour', created_at AT TIME ZONE 'UTC') AS hour,
+       AVG(score) AS avg_score,
+       COUNT(*) AS snapshots,
+       COALESCE(SUM(volume), 0) AS volume,
+       MAX(created_at) AS last_snapshot_at
+FROM sentiment_snapshots
+GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX idx_party_hourly_scores ON party_hourly_scores (party_id, hour);
+
+-- Table: leaders
+-- Seeded on first start from parties.leader.
+CREATE TABLE leaders (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    aliases JSONB DEFAULT '[]', -- e.g. ["EPS", "எடப்பாடி"]
+    party_id INTEGER,
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_leaders_name ON leaders(name);
+CREATE INDEX idx_leaders_party_id ON leaders(party_id);
+
+-- Table: leader_snapshots
+-- party_id is the party whose run rated the leader, not necessarily theirs.
+CREATE TABLE leader_snapshots (
+    id SERIAL PRIMARY KEY,
+    leader_id INTEGER NOT NULL,
+    party_id INTEGER,
+    snapshot_id INTEGER, -- sentiment_snapshots row from the same run
+    score DOUBLE PRECISION, -- 0-100
+    emotion VARCHAR(255),
+    mentions INTEGER DEFAULT 0,
+    backfilled BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_leader_snapshots_leader_id ON leader_snapshots(leader_id);
+CREATE INDEX idx_leader_snapshots_snapshot_id ON leader_snapshots(snapshot_id);
+CREATE INDEX idx_leader_snapshots_created_at ON leader_snapshots(created_at);
+
+-- Table: watched_channels
+-- YouTube channels whose uploads are sampled through the uploads playlist.
+-- party_id NULL means a news channel watched for every party.
+CREATE TABLE watched_channels (
+    id SERIAL PRIMARY KEY,
+    party_id INTEGER REFERENCES parties(id),
+    channel_id VARCHAR(64) NOT NULL,
+    name VARCHAR(255),
+    kind VARCHAR(20), -- party, leader, news
+    uploads_playlist_id VARCHAR(64),
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_watched_channels_party_id ON watched_channels(party_id);
+CREATE INDEX idx_watched_channels_channel_id ON watched_channels(channel_id);
+
+-- Table: quota_usages
+-- Daily API usage per provider and operation. day is the provider's quota
+-- day (Pacific time for YouTube).
+CREATE TABLE quota_usages (
+    id SERIAL PRIMARY KEY,
+    service VARCHAR(50) NOT NULL,
+    day VARCHAR(10) NOT NULL, -- YYYY-MM-DD
+    operation VARCHAR(100) NOT NULL,
+    units INTEGER DEFAULT 0,
+    calls INTEGER DEFAULT 0,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_quota_usage_key ON quota_usages(service, day, operation);
+
+-- Table: source_targets
+-- Subreddits, RSS feeds and news domains, global or per party or region.
+-- RSS values may contain a {query} placeholder.
+CREATE TABLE source_targets (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL, -- subreddit, rss, news_domain
+    value TEXT NOT NULL,
+    label VARCHAR(255),
+    scope VARCHAR(20) NOT NULL DEFAULT 'global', -- global, party, region
+    party_id INTEGER,
+    region VARCHAR(255),
+    active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_source_targets_kind ON source_targets(kind);
+CREATE INDEX idx_source_targets_party_id ON source_targets(party_id);
+
+-- Table: feeds
+-- Fetch state and health of each RSS feed URL.
+CREATE TABLE feeds (
+    id SERIAL PRIMARY KEY,
+    target_id INTEGER,
+    url TEXT NOT NULL,
+    source VARCHAR(255),
+    etag TEXT,
+    last_modified TEXT,
+    last_fetched_at TIMESTAMPTZ,
+    last_success_at TIMESTAMPTZ,
+    last_error TEXT,
+    consecutive_failures INTEGER DEFAULT 0,
+    avg_latency_ms DOUBLE PRECISION DEFAULT 0,
+    disabled BOOLEAN DEFAULT FALSE,
+    disabled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_feeds_url ON feeds(url);
+CREATE INDEX idx_feeds_target_id ON feeds(target_id);
+CREATE INDEX idx_feeds_disabled ON feeds(disabled);
+
+-- Table: feed_items
+-- RSS items already ingested, keyed by feed and GUID.
+CREATE TABLE feed_items (
+    id SERIAL PRIMARY KEY,
+    feed_id INTEGER NOT NULL,
+    guid TEXT NOT NULL,
+    title TEXT,
+    link TEXT,
+    description TEXT,
+    content TEXT,
+    body TEXT, -- Extracted article text
+    published_at TIMESTAMPTZ, -- NULL when the feed gave no usable date
+    fetched_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_feed_items_guid ON feed_items(feed_id, guid);
+CREATE INDEX idx_feed_items_published_at ON feed_items(published_at);
+
+-- Table: backfill_buckets
+-- One row per party and backfill bucket, so reruns skip finished work.
+CREATE TABLE backfill_buckets (
+    id SERIAL PRIMARY KEY,
+    party_id INTEGER NOT NULL,
+    bucket_start TIMESTAMPTZ NOT NULL,
+    bucket_end TIMESTAMPTZ,
+    status VARCHAR(20), -- done, partial, empty, failed
+    snapshot_id INTEGER,
+    items INTEGER DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_backfill_bucket ON backfill_buckets(party_id, bucket_start);
+
+-- Table: constituencies
+-- The 234 assembly seats, filled by the results import.
+CREATE TABLE constituencies (
+    id SERIAL PRIMARY KEY,
+    number INTEGER NOT NULL, -- ECI constituency number
+    name VARCHAR(255) NOT NULL,
+    district VARCHAR(255),
+    region VARCHAR(255),
+    reserved VARCHAR(10), -- SC, ST or empty
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_constituencies_number ON constituencies(number);
+
+-- Table: election_results
+-- Votes per party and constituency at past elections. party_id NULL means
+-- an untracked party, counted as Others.
+CREATE TABLE election_results (
+    id SERIAL PRIMARY KEY,
+    year INTEGER NOT NULL,
+    constituency_id INTEGER NOT NULL,
+    party_name VARCHAR(255) NOT NULL, -- As written in the source data
+    party_id INTEGER,
+    candidate VARCHAR(255),
+    votes INTEGER DEFAULT 0,
+    vote_share DOUBLE PRECISION DEFAULT 0, -- Percent of valid votes
+    won BOOLEAN DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX idx_election_result ON election_results(year, constituency_id, party_name);
+CREATE INDEX idx_election_results_party_id ON election_results(party_id);
+
+-- Table: polls
+CREATE TABLE polls (
+    id SERIAL PRIMARY KEY,
+    pollster VARCHAR(255) NOT NULL,
+    date TIMESTAMPTZ NOT NULL, -- Last day of fieldwork, or publication
+    sample_size INTEGER DEFAULT 0,
+    source_url TEXT,
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+CREATE UNIQUE INDEX idx_poll_key ON polls(pollster, date);
+
+-- Table: poll_shares
+CREATE TABLE poll_shares (
+    id SERIAL PRIMARY KEY,
+    poll_id INTEGER NOT NULL REFERENCES polls(id),
+    party_name VARCHAR(255),
+    party_id INTEGER,
+    share DOUBLE PRECISION -- Percent
+);
+
+CREATE INDEX idx_poll_shares_poll_id ON poll_shares(poll_id);
+CREATE INDEX idx_poll_shares_party_id ON poll_shares(party_id);
+
 -- Optional: Seed Data to get started
 INSERT INTO parties (name, leader, color_hex) VALUES 
 ('DMK', 'M.K. Stalin', '#dd2e44'),
@@ -31,3 +284,8 @@ INSERT INTO parties (name, leader, color_hex) VALUES
 ('TVK', 'Vijay', '#f1c40f'),
 ('BJP', 'K. Annamalai', '#f39c12'),
 ('NTK', 'Seeman', '#e74c3c');
+
+-- Watchlist entries are added per deployment, e.g.:
+-- INSERT INTO watched_channels (party_id, channel_id, name, kind) VALUES
+-- ((SELECT id FROM parties WHERE name = 'DMK'), '<channel id>', 'DMK Official', 'party'),
+-- (NULL, '<channel id>', 'Tamil news channel', 'news');
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireAdmin guards the admin API with a static bearer token from
+// ADMIN_TOKEN. The admin API is disabled when the token is not configured.
+func RequireAdmin(c *fiber.Ctx) error {
+	token := os.Getenv("ADMIN_TOKEN")
+	if token == "" {
+		return c.Status(503).JSON(fiber.Map{"error": "Admin API is disabled, set ADMIN_TOKEN to enable it"})
+	}
+
+	given := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+	return c.Next()
+}
+
+func setupAdminRoutes(api fiber.Router) {
+	admin := api.Group("/admin", RequireAdmin)
+
+	admin.Get("/targets", ListTargets)
+	admin.Post("/targets", CreateTarget)
+	admin.Put("/targets/:id", UpdateTarget)
+	admin.Delete("/targets/:id", DeleteTarget)
+
+	admin.Get("/feeds", ListFeeds)
+	admin.Post("/feeds/:id/enable", EnableFeed)
+
+	setupPartyAdminRoutes(admin)
+	setupAllianceAdminRoutes(admin)
+	setupLeaderAdminRoutes(admin)
+
+	admin.Post("/breakers/:name/reset", ResetBreaker)
+
+	admin.Post("/results/import", ImportResults)
+	admin.Post("/polls/import", ImportPolls)
+}
+
+func ListTargets(c *fiber.Ctx) error {
+	q := db.DB.Order("kind, id")
+	if kind := c.Query("kind"); kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	if partyID := c.QueryInt("party_id"); partyID != 0 {
+		q = q.Where("party_id = ?", partyID)
+	}
+	if region := c.Query("region"); region != "" {
+		q = q.Where("region = ?", region)
+	}
+
+	var targets []models.SourceTarget
+	if err := q.Find(&targets).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(targets)
+}
+
+type TargetRequest struct {
+	Kind    string `json:"kind"`
+	Value   string `json:"value"`
+	Label   string `json:"label"`
+	Scope   string `json:"scope"`
+	PartyID *uint  `json:"party_id"`
+	Region  string `json:"region"`
+	Active  *bool  `json:"active"`
+}
+
+func CreateTarget(c *fiber.Ctx) error {
+	var req TargetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	target := models.SourceTarget{Active: true}
+	if msg := applyTargetRequest(&target, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+
+	if err := db.DB.Create(&target).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(target)
+}
+
+func UpdateTarget(c *fiber.Ctx) error {
+	var target models.SourceTarget
+	if err := db.DB.First(&target, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Target not found"})
+	}
+
+	// Start from the stored values so partial updates work
+	req := TargetRequest{
+		Kind:    target.Kind,
+		Value:   target.Value,
+		Label:   target.Label,
+		Scope:   target.Scope,
+		PartyID: target.PartyID,
+		Region:  target.Region,
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if msg := applyTargetRequest(&target, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+
+	if err := db.DB.Save(&target).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(target)
+}
+
+func DeleteTarget(c *fiber.Ctx) error {
+	result := db.DB.Delete(&models.SourceTarget{}, c.Params("id"))
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Target not found"})
+	}
+	return c.SendStatus(204)
+}
+
+var subredditName = regexp.MustCompile(`^[A-Za-z0-9_]{2,21}$`)
+
+// applyTargetRequest validates req and copies it onto target. It returns a
+// user-facing error message, or "" if the request is valid.
+func applyTargetRequest(target *models.SourceTarget, req TargetRequest) string {
+	req.Value = strings.TrimSpace(req.Value)
+	if req.Scope == "" {
+		req.Scope = services.ScopeGlobal
+	}
+
+	switch req.Kind {
+	case services.TargetSubreddit:
+		req.Value = strings.TrimPrefix(req.Value, "r/")
+		if !subredditName.MatchString(req.Value) {
+			return "Invalid subreddit name"
+		}
+	case services.TargetRSS:
+		u, err := url.Parse(req.Value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "RSS target must be an http(s) URL"
+		}
+	case services.TargetNewsDomain:
+		if req.Value == "" || strings.Contains(req.Value, "/") {
+			return "News domain must be a bare host name, e.g. dinamalar.com"
+		}
+		req.Value = strings.ToLower(req.Value)
+	default:
+		return "kind must be one of subreddit, rss, news_domain"
+	}
+
+	switch req.Scope {
+	case services.ScopeGlobal:
+		req.PartyID, req.Region = nil, ""
+	case services.ScopeParty:
+		if req.PartyID == nil {
+			return "party_id is required for party scope"
+		}
+		var party models.Party
+		if err := db.DB.First(&party, *req.PartyID).Error; err != nil {
+			return "Party not found"
+		}
+		req.Region = ""
+	case services.ScopeRegion:
+		if req.Region == "" {
+			return "region is required for region scope"
+		}
+		req.PartyID = nil
+	default:
+		return "scope must be one of global, party, region"
+	}
+
+	target.Kind = req.Kind
+	target.Value = req.Value
+	target.Label = req.Label
+	target.Scope = req.Scope
+	target.PartyID = req.PartyID
+	target.Region = req.Region
+	if req.Active != nil {
+		target.Active = *req.Active
+	}
+	return ""
+}
+
+func ListFeeds(c *fiber.Ctx) error {
+	feeds, err := services.ListFeeds()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(feeds)
+}
+
+func EnableFeed(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid feed id"})
+	}
+	feed, err := services.EnableFeed(uint(id))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Feed not found"})
+	}
+	return c.JSON(feed)
+}
+
+func ResetBreaker(c *fiber.Ctx) error {
+	if !services.ResetBreaker(c.Params("name")) {
+		return c.Status(404).JSON(fiber.Map{"error": "Breaker not found"})
+	}
+	return c.SendStatus(204)
+}
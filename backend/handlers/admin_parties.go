@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func setupPartyAdminRoutes(admin fiber.Router) {
+	admin.Get("/parties", ListPartiesAdmin)
+	admin.Post("/parties", CreateParty)
+	admin.Put("/parties/:id", UpdateParty)
+	admin.Delete("/parties/:id", DeleteParty)
+	admin.Post("/parties/:id/restore", RestoreParty)
+}
+
+// ListPartiesAdmin lists every party with its alliance history, including
+// inactive ones and, with ?deleted=true, soft-deleted ones.
+func ListPartiesAdmin(c *fiber.Ctx) error {
+	q := db.DB.Preload("Memberships", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("since desc")
+	}).Preload("Memberships.Alliance").Order("id")
+	if c.QueryBool("deleted") {
+		q = q.Unscoped()
+	}
+
+	var parties []models.Party
+	if err := q.Find(&parties).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(parties)
+}
+
+type PartyRequest struct {
+	Name     string   `json:"name"`
+	Leader   string   `json:"leader"`
+	ColorHex string   `json:"color_hex"`
+	LogoURL  string   `json:"logo_url"`
+	Aliases  []string `json:"aliases"`
+	Active   *bool    `json:"active"`
+
+	// Alliance moves the party into the named alliance (created if new) as of
+	// AllianceSince, default today. "" leaves its current alliance; omit the
+	// field to keep it unchanged.
+	Alliance      *string `json:"alliance"`
+	AllianceSince string  `json:"alliance_since"` // YYYY-MM-DD
+}
+
+func CreateParty(c *fiber.Ctx) error {
+	var req PartyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	party := models.Party{Active: true}
+	if msg := applyPartyRequest(&party, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	return saveParty(c, &party, req, 201)
+}
+
+func UpdateParty(c *fiber.Ctx) error {
+	var party models.Party
+	if err := db.DB.First(&party, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Party not found"})
+	}
+
+	// Start from the stored values so partial updates work
+	req := PartyRequest{
+		Name:     party.Name,
+		Leader:   party.Leader,
+		ColorHex: party.ColorHex,
+		LogoURL:  party.LogoURL,
+		Aliases:  party.Aliases,
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if msg := applyPartyRequest(&party, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	return saveParty(c, &party, req, 200)
+}
+
+// saveParty writes the party and any alliance change in one transaction.
+func saveParty(c *fiber.Ctx, party *models.Party, req PartyRequest, status int) error {
+	since := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.AllianceSince != "" {
+		t, err := time.Parse("2006-01-02", req.AllianceSince)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "alliance_since must be YYYY-MM-DD"})
+		}
+		since = t
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Memberships").Save(party).Error; err != nil {
+			return err
+		}
+		if !party.Active {
+			// Create skips false because of the column default
+			if err := tx.Model(party).Update("active", false).Error; err != nil {
+				return err
+			}
+		}
+		if req.Alliance != nil {
+			return services.SetPartyAlliance(tx, party.ID, strings.TrimSpace(*req.Alliance), since)
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	db.DB.Preload("Memberships.Alliance").First(party, party.ID)
+	return c.Status(status).JSON(party)
+}
+
+// DeleteParty soft-deletes a party. Its snapshots stay, and it can be
+// brought back with /restore.
+func DeleteParty(c *fiber.Ctx) error {
+	result := db.DB.Delete(&models.Party{}, c.Params("id"))
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Party not found"})
+	}
+	return c.SendStatus(204)
+}
+
+func RestoreParty(c *fiber.Ctx) error {
+	var party models.Party
+	if err := db.DB.Unscoped().First(&party, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Party not found"})
+	}
+	if msg := checkPartyNames(party.ID, party.Name, party.Aliases); msg != "" {
+		return c.Status(409).JSON(fiber.Map{"error": msg})
+	}
+	if err := db.DB.Unscoped().Model(&party).Update("deleted_at", nil).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(party)
+}
+
+var colorHex = regexp.MustCompile(`^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$`)
+
+// applyPartyRequest validates req and copies it onto party. It returns a
+// user-facing error message, or "" if the request is valid.
+func applyPartyRequest(party *models.Party, req PartyRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if !colorHex.MatchString(req.ColorHex) {
+		return "color_hex must look like #E31E24"
+	}
+	if req.LogoURL != "" {
+		u, err := url.Parse(req.LogoURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "logo_url must be an http(s) URL"
+		}
+	}
+
+	var aliases models.StringList
+	seen := map[string]bool{strings.ToLower(req.Name): true}
+	for _, a := range req.Aliases {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		seen[strings.ToLower(a)] = true
+		aliases = append(aliases, a)
+	}
+	if msg := checkPartyNames(party.ID, req.Name, aliases); msg != "" {
+		return msg
+	}
+
+	party.Name = req.Name
+	party.Leader = strings.TrimSpace(req.Leader)
+	party.ColorHex = strings.ToUpper(req.ColorHex)
+	party.LogoURL = req.LogoURL
+	party.Aliases = aliases
+	if req.Active != nil {
+		party.Active = *req.Active
+	}
+	return ""
+}
+
+// checkPartyNames makes sure a name or alias can't resolve to two parties.
+func checkPartyNames(selfID uint, name string, aliases []string) string {
+	var others []models.Party
+	db.DB.Where("id <> ?", selfID).Find(&others)
+
+	taken := make(map[string]string)
+	for _, p := range others {
+		taken[strings.ToLower(p.Name)] = p.Name
+		for _, a := range p.Aliases {
+			taken[strings.ToLower(a)] = p.Name
+		}
+	}
+	for _, n := range append([]string{name}, aliases...) {
+		if owner, ok := taken[strings.ToLower(n)]; ok {
+			return "\"" + n + "\" is already used by " + owner
+		}
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"testing"
+
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+)
+
+func TestApplyTargetRequest(t *testing.T) {
+	partyID := uint(1)
+	tests := []struct {
+		name    string
+		req     TargetRequest
+		wantMsg string
+		want    models.SourceTarget
+	}{
+		{
+			name: "subreddit",
+			req:  TargetRequest{Kind: services.TargetSubreddit, Value: " r/TamilNadu "},
+			want: models.SourceTarget{Kind: services.TargetSubreddit, Value: "TamilNadu", Scope: services.ScopeGlobal},
+		},
+		{
+			name:    "bad subreddit",
+			req:     TargetRequest{Kind: services.TargetSubreddit, Value: "tamil nadu"},
+			wantMsg: "Invalid subreddit name",
+		},
+		{
+			name: "rss with query",
+			req:  TargetRequest{Kind: services.TargetRSS, Value: "https://news.google.com/rss/search?q={query}", Label: "Google News"},
+			want: models.SourceTarget{Kind: services.TargetRSS, Value: "https://news.google.com/rss/search?q={query}", Label: "Google News", Scope: services.ScopeGlobal},
+		},
+		{
+			name:    "rss without scheme",
+			req:     TargetRequest{Kind: services.TargetRSS, Value: "dinamalar.com/rss"},
+			wantMsg: "RSS target must be an http(s) URL",
+		},
+		{
+			name: "news domain in a region",
+			req:  TargetRequest{Kind: services.TargetNewsDomain, Value: "Dinamalar.com", Scope: services.ScopeRegion, Region: "Kongu", PartyID: &partyID},
+			want: models.SourceTarget{Kind: services.TargetNewsDomain, Value: "dinamalar.com", Scope: services.ScopeRegion, Region: "Kongu"},
+		},
+		{
+			name:    "news domain with a path",
+			req:     TargetRequest{Kind: services.TargetNewsDomain, Value: "dinamalar.com/news"},
+			wantMsg: "News domain must be a bare host name, e.g. dinamalar.com",
+		},
+		{
+			name:    "unknown kind",
+			req:     TargetRequest{Kind: "twitter", Value: "x"},
+			wantMsg: "kind must be one of subreddit, rss, news_domain",
+		},
+		{
+			name:    "region scope without region",
+			req:     TargetRequest{Kind: services.TargetSubreddit, Value: "Chennai", Scope: services.ScopeRegion},
+			wantMsg: "region is required for region scope",
+		},
+		{
+			name:    "party scope without party",
+			req:     TargetRequest{Kind: services.TargetSubreddit, Value: "Chennai", Scope: services.ScopeParty},
+			wantMsg: "party_id is required for party scope",
+		},
+		{
+			name:    "unknown scope",
+			req:     TargetRequest{Kind: services.TargetSubreddit, Value: "Chennai", Scope: "district"},
+			wantMsg: "scope must be one of global, party, region",
+		},
+	}
+	for _, tt := range tests {
+		var got models.SourceTarget
+		msg := applyTargetRequest(&got, tt.req)
+		if msg != tt.wantMsg {
+			t.Errorf("%s: message %q, want %q", tt.name, msg, tt.wantMsg)
+			continue
+		}
+		if msg == "" && (got.Kind != tt.want.Kind || got.Value != tt.want.Value || got.Label != tt.want.Label ||
+			got.Scope != tt.want.Scope || got.Region != tt.want.Region || got.PartyID != nil) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
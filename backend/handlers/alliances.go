@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func setupAllianceRoutes(api fiber.Router) {
+	api.Get("/alliances", ListAlliances)
+	api.Get("/alliances/compare", CompareAlliances)
+	api.Get("/alliances/:id/history", GetAllianceHistory)
+}
+
+func setupAllianceAdminRoutes(admin fiber.Router) {
+	admin.Post("/alliances", CreateAlliance)
+	admin.Put("/alliances/:id", UpdateAlliance)
+	admin.Delete("/alliances/:id", DeleteAlliance)
+	admin.Post("/alliances/:id/members", AddAllianceMember)
+	admin.Put("/alliances/:id/members/:member_id", UpdateAllianceMember)
+	admin.Delete("/alliances/:id/members/:member_id", DeleteAllianceMember)
+}
+
+// ListAlliances returns alliances with their current members.
+// ?history=true includes past memberships too.
+func ListAlliances(c *fiber.Ctx) error {
+	history := c.QueryBool("history")
+	var alliances []models.Alliance
+	err := db.DB.Preload("Memberships", func(tx *gorm.DB) *gorm.DB {
+		if !history {
+			tx = tx.Where("until IS NULL")
+		}
+		return tx.Order("since")
+	}).Order("id").Find(&alliances).Error
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(alliances)
+}
+
+// seriesParams reads the shared ?days= and ?weighting= parameters.
+func seriesParams(c *fiber.Ctx) (from, to time.Time, weighting string, msg string) {
+	days := c.QueryInt("days", 30)
+	if days <= 0 || days > 365 {
+		return from, to, "", "days must be between 1 and 365"
+	}
+	weighting = c.Query("weighting", services.DefaultWeighting())
+	if !services.ValidWeighting(weighting) {
+		return from, to, "", "weighting must be one of equal, seats, votes"
+	}
+	to = time.Now().UTC()
+	from = to.AddDate(0, 0, -days)
+	return from, to, weighting, ""
+}
+
+func GetAllianceHistory(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid alliance id"})
+	}
+	from, to, weighting, msg := seriesParams(c)
+	if msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+
+	series, err := services.AllianceHistory(uint(id), from, to, weighting)
+	if err != nil {
+		return lookupError(c, err, "Alliance not found")
+	}
+	return c.JSON(series)
+}
+
+// CompareAlliances lines up several alliances on one date axis. Scores are
+// null on days an alliance has no data.
+func CompareAlliances(c *fiber.Ctx) error {
+	var ids []uint
+	for _, part := range strings.Split(c.Query("ids"), ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	if len(ids) < 2 {
+		return c.Status(400).JSON(fiber.Map{"error": "ids must list at least two alliance ids, e.g. ids=1,2"})
+	}
+	from, to, weighting, msg := seriesParams(c)
+	if msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+
+	type compared struct {
+		AllianceID uint       `json:"alliance_id"`
+		Name       string     `json:"name"`
+		ColorHex   string     `json:"color_hex"`
+		Weighting  string     `json:"weighting"`
+		Scores     []*float64 `json:"scores"` // Aligned with dates
+	}
+
+	dateSet := make(map[string]bool)
+	var all []*services.AllianceSeries
+	for _, id := range ids {
+		series, err := services.AllianceHistory(id, from, to, weighting)
+		if err != nil {
+			return lookupError(c, err, "Alliance "+strconv.Itoa(int(id))+" not found")
+		}
+		for _, p := range series.Points {
+			dateSet[p.Date] = true
+		}
+		all = append(all, series)
+	}
+
+	dates := make([]string, 0, len(dateSet))
+	for d := range dateSet {
+		dates = append(dates, d)
+	}
+	sort.Strings(dates)
+
+	out := make([]compared, 0, len(all))
+	for _, series := range all {
+		byDate := make(map[string]float64, len(series.Points))
+		for _, p := range series.Points {
+			byDate[p.Date] = p.Score
+		}
+		row := compared{AllianceID: series.AllianceID, Name: series.Name, ColorHex: series.ColorHex, Weighting: series.Weighting}
+		for _, d := range dates {
+			if score, ok := byDate[d]; ok {
+				row.Scores = append(row.Scores, &score)
+			} else {
+				row.Scores = append(row.Scores, nil)
+			}
+		}
+		out = append(out, row)
+	}
+
+	return c.JSON(fiber.Map{"dates": dates, "alliances": out})
+}
+
+type AllianceRequest struct {
+	Name        string `json:"name"`
+	ColorHex    string `json:"color_hex"`
+	Description string `json:"description"`
+}
+
+func CreateAlliance(c *fiber.Ctx) error {
+	var req AllianceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	var alliance models.Alliance
+	if msg := applyAllianceRequest(&alliance, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	if err := db.DB.Create(&alliance).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(alliance)
+}
+
+func UpdateAlliance(c *fiber.Ctx) error {
+	var alliance models.Alliance
+	if err := db.DB.First(&alliance, c.Params("id")).Error; err != nil {
+		return lookupError(c, err, "Alliance not found")
+	}
+	req := AllianceRequest{Name: alliance.Name, ColorHex: alliance.ColorHex, Description: alliance.Description}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if msg := applyAllianceRequest(&alliance, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	if err := db.DB.Omit("Memberships").Save(&alliance).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(alliance)
+}
+
+// DeleteAlliance removes an alliance and its membership history.
+func DeleteAlliance(c *fiber.Ctx) error {
+	var rows int64
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("alliance_id = ?", c.Params("id")).Delete(&models.AllianceMembership{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Alliance{}, c.Params("id"))
+		rows = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if rows == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Alliance not found"})
+	}
+	return c.SendStatus(204)
+}
+
+func applyAllianceRequest(alliance *models.Alliance, req AllianceRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if req.ColorHex != "" && !colorHex.MatchString(req.ColorHex) {
+		return "color_hex must look like #E31E24"
+	}
+	var clash models.Alliance
+	if db.DB.Where("LOWER(name) = LOWER(?) AND id <> ?", req.Name, alliance.ID).First(&clash).Error == nil {
+		return "An alliance with that name already exists"
+	}
+
+	alliance.Name = req.Name
+	alliance.ColorHex = strings.ToUpper(req.ColorHex)
+	alliance.Description = req.Description
+	return ""
+}
+
+type MemberRequest struct {
+	PartyID   uint     `json:"party_id"`
+	Since     string   `json:"since"` // YYYY-MM-DD
+	Until     *string  `json:"until"` // YYYY-MM-DD, null while current
+	Seats     *int     `json:"seats"`
+	VoteShare *float64 `json:"vote_share"`
+}
+
+// AddAllianceMember adds a party to the alliance. A party can only be in one
+// alliance at a time, so its other current membership is closed on Since.
+func AddAllianceMember(c *fiber.Ctx) error {
+	var alliance models.Alliance
+	if err := db.DB.First(&alliance, c.Params("id")).Error; err != nil {
+		return lookupError(c, err, "Alliance not found")
+	}
+	var req MemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	var party models.Party
+	if req.PartyID == 0 || db.DB.First(&party, req.PartyID).Error != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "party_id must be an existing party"})
+	}
+
+	member := models.AllianceMembership{AllianceID: alliance.ID, PartyID: party.ID}
+	if msg := applyMemberRequest(&member, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if member.Until == nil {
+			err := tx.Model(&models.AllianceMembership{}).
+				Where("party_id = ? AND until IS NULL", party.ID).
+				Update("until", member.Since).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(&member).Error
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(member)
+}
+
+func UpdateAllianceMember(c *fiber.Ctx) error {
+	var member models.AllianceMembership
+	if err := db.DB.Where("alliance_id = ?", c.Params("id")).First(&member, c.Params("member_id")).Error; err != nil {
+		return lookupError(c, err, "Membership not found")
+	}
+	req := MemberRequest{Since: member.Since.Format("2006-01-02")}
+	if member.Until != nil {
+		until := member.Until.Format("2006-01-02")
+		req.Until = &until
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if msg := applyMemberRequest(&member, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	if err := db.DB.Omit("Alliance").Save(&member).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(member)
+}
+
+func DeleteAllianceMember(c *fiber.Ctx) error {
+	result := db.DB.Where("alliance_id = ?", c.Params("id")).Delete(&models.AllianceMembership{}, c.Params("member_id"))
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Membership not found"})
+	}
+	return c.SendStatus(204)
+}
+
+func applyMemberRequest(member *models.AllianceMembership, req MemberRequest) string {
+	since := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.Since != "" {
+		t, err := time.Parse("2006-01-02", req.Since)
+		if err != nil {
+			return "since must be YYYY-MM-DD"
+		}
+		since = t
+	}
+	var until *time.Time
+	if req.Until != nil && *req.Until != "" {
+		t, err := time.Parse("2006-01-02", *req.Until)
+		if err != nil {
+			return "until must be YYYY-MM-DD"
+		}
+		if !t.After(since) {
+			return "until must be after since"
+		}
+		until = &t
+	}
+	if req.Seats != nil {
+		if *req.Seats < 0 || *req.Seats > 234 {
+			return "seats must be between 0 and 234"
+		}
+		member.Seats = *req.Seats
+	}
+	if req.VoteShare != nil {
+		if *req.VoteShare < 0 || *req.VoteShare > 100 {
+			return "vote_share is a percentage between 0 and 100"
+		}
+		member.VoteShare = *req.VoteShare
+	}
+	member.Since = since
+	member.Until = until
+	return ""
+}
+
+// lookupError answers 404 with msg when err means the row doesn't exist, and
+// 500 for anything else, so a database outage isn't reported as missing data.
+func lookupError(c *fiber.Ctx, err error, msg string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": msg})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
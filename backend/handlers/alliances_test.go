@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"election-pulse-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func TestApplyMemberRequest(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+	share := func(f float64) *float64 { return &f }
+	day := func(s string) time.Time {
+		t, _ := time.Parse("2006-01-02", s)
+		return t
+	}
+
+	tests := []struct {
+		name      string
+		req       MemberRequest
+		wantMsg   string
+		since     time.Time
+		until     *time.Time
+		seats     int
+		voteShare float64
+	}{
+		{
+			name:      "open-ended",
+			req:       MemberRequest{Since: "2026-01-15", Seats: num(25), VoteShare: share(4.3)},
+			since:     day("2026-01-15"),
+			seats:     25,
+			voteShare: 4.3,
+		},
+		{
+			name:  "closed",
+			req:   MemberRequest{Since: "2021-01-01", Until: str("2023-09-25")},
+			since: day("2021-01-01"),
+			until: func() *time.Time { t := day("2023-09-25"); return &t }(),
+		},
+		{
+			name:  "empty until is open-ended",
+			req:   MemberRequest{Since: "2021-01-01", Until: str("")},
+			since: day("2021-01-01"),
+		},
+		{"bad since", MemberRequest{Since: "15/01/2026"}, "since must be YYYY-MM-DD", time.Time{}, nil, 0, 0},
+		{"bad until", MemberRequest{Since: "2026-01-15", Until: str("soon")}, "until must be YYYY-MM-DD", time.Time{}, nil, 0, 0},
+		{"until before since", MemberRequest{Since: "2026-01-15", Until: str("2026-01-15")}, "until must be after since", time.Time{}, nil, 0, 0},
+		{"too many seats", MemberRequest{Seats: num(235)}, "seats must be between 0 and 234", time.Time{}, nil, 0, 0},
+		{"negative seats", MemberRequest{Seats: num(-1)}, "seats must be between 0 and 234", time.Time{}, nil, 0, 0},
+		{"share over 100", MemberRequest{VoteShare: share(101)}, "vote_share is a percentage between 0 and 100", time.Time{}, nil, 0, 0},
+	}
+	for _, tt := range tests {
+		var m models.AllianceMembership
+		msg := applyMemberRequest(&m, tt.req)
+		if msg != tt.wantMsg {
+			t.Errorf("%s: message %q, want %q", tt.name, msg, tt.wantMsg)
+			continue
+		}
+		if msg != "" {
+			continue
+		}
+		if !m.Since.Equal(tt.since) || m.Seats != tt.seats || m.VoteShare != tt.voteShare {
+			t.Errorf("%s: got since=%v seats=%d vote_share=%v", tt.name, m.Since, m.Seats, m.VoteShare)
+		}
+		if (m.Until == nil) != (tt.until == nil) || (m.Until != nil && !m.Until.Equal(*tt.until)) {
+			t.Errorf("%s: got until=%v, want %v", tt.name, m.Until, tt.until)
+		}
+	}
+}
+
+func TestLookupError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{gorm.ErrRecordNotFound, 404},
+		{fmt.Errorf("alliance 3: %w", gorm.ErrRecordNotFound), 404},
+		{errors.New("connection refused"), 500},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error { return lookupError(c, tt.err, "Alliance not found") })
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("lookupError(%v) = %d, want %d", tt.err, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// compareParams reads the parties and days of a comparison, or returns the
+// status and message to reject the request with.
+func compareParams(c *fiber.Ctx) ([]models.Party, int, int, string) {
+	var parties []models.Party
+	seen := make(map[uint]bool)
+	for _, ref := range strings.Split(c.Query("parties"), ",") {
+		if strings.TrimSpace(ref) == "" {
+			continue
+		}
+		party, err := services.ResolveParty(ref)
+		if err != nil {
+			return nil, 0, 404, "Party not found: " + strings.TrimSpace(ref)
+		}
+		if !seen[party.ID] {
+			seen[party.ID] = true
+			parties = append(parties, party)
+		}
+	}
+	if len(parties) < 2 || len(parties) > 6 {
+		return nil, 0, 400, "parties must list 2 to 6 parties, e.g. parties=DMK,AIADMK,TVK"
+	}
+
+	days := c.QueryInt("days", 30)
+	if days <= 0 || days > 365 {
+		return nil, 0, 400, "days must be between 1 and 365"
+	}
+	return parties, days, 0, ""
+}
+
+// CompareParties puts several parties side by side from stored snapshots.
+func CompareParties(c *fiber.Ctx) error {
+	parties, days, status, msg := compareParams(c)
+	if msg != "" {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+	to := time.Now().UTC()
+	cmp, err := services.CompareParties(parties, to.AddDate(0, 0, -days), to)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(cmp)
+}
+
+// CompareJoint is CompareParties plus one combined analysis so the scores
+// share a scale. It fetches fresh data for every party, so it is a POST and
+// goes through the same coalescing and quota checks as /analyze.
+func CompareJoint(c *fiber.Ctx) error {
+	parties, days, status, msg := compareParams(c)
+	if msg != "" {
+		return c.Status(status).JSON(fiber.Map{"error": msg})
+	}
+	to := time.Now().UTC()
+	cmp, err := services.CompareParties(parties, to.AddDate(0, 0, -days), to)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	joint, err := services.CompareJoint(c.UserContext(), parties, analyzeTimeout())
+	if errors.Is(err, services.ErrQuotaExhausted) {
+		return c.Status(429).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		fmt.Printf("Joint analysis failed: %v\n", err)
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	cmp.Joint = joint
+	return c.JSON(cmp)
+}
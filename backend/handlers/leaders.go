@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupLeaderRoutes(api fiber.Router) {
+	api.Get("/leaders", ListLeaders)
+	api.Get("/leaders/:id/history", GetLeaderHistory)
+}
+
+func setupLeaderAdminRoutes(admin fiber.Router) {
+	admin.Post("/leaders", CreateLeader)
+	admin.Put("/leaders/:id", UpdateLeader)
+	admin.Delete("/leaders/:id", DeleteLeader)
+}
+
+// ListLeaders returns tracked leaders. Inactive ones are only included with
+// ?all=true.
+func ListLeaders(c *fiber.Ctx) error {
+	q := db.DB.Order("id")
+	if !c.QueryBool("all") {
+		q = q.Where("active = ?", true)
+	}
+	var leaders []models.Leader
+	if err := q.Find(&leaders).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(leaders)
+}
+
+// GetLeaderHistory returns a leader's daily rating next to their party's.
+func GetLeaderHistory(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid leader id"})
+	}
+	days := c.QueryInt("days", 30)
+	if days <= 0 || days > 365 {
+		return c.Status(400).JSON(fiber.Map{"error": "days must be between 1 and 365"})
+	}
+
+	to := time.Now().UTC()
+	series, err := services.LeaderHistory(uint(id), to.AddDate(0, 0, -days), to)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Leader not found"})
+	}
+	return c.JSON(series)
+}
+
+type LeaderRequest struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	PartyID *uint    `json:"party_id"`
+	Active  *bool    `json:"active"`
+}
+
+func CreateLeader(c *fiber.Ctx) error {
+	var req LeaderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	leader := models.Leader{Active: true}
+	if msg := applyLeaderRequest(&leader, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	if err := db.DB.Create(&leader).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !leader.Active {
+		// Create skips false because of the column default
+		db.DB.Model(&leader).Update("active", false)
+	}
+	return c.Status(201).JSON(leader)
+}
+
+func UpdateLeader(c *fiber.Ctx) error {
+	var leader models.Leader
+	if err := db.DB.First(&leader, c.Params("id")).Error; err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Leader not found"})
+	}
+	req := LeaderRequest{Name: leader.Name, Aliases: leader.Aliases, PartyID: leader.PartyID}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if msg := applyLeaderRequest(&leader, req); msg != "" {
+		return c.Status(400).JSON(fiber.Map{"error": msg})
+	}
+	if err := db.DB.Save(&leader).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(leader)
+}
+
+// DeleteLeader stops tracking a leader. Their snapshots are kept; set
+// "active": false instead to pause tracking without losing the entry.
+func DeleteLeader(c *fiber.Ctx) error {
+	result := db.DB.Delete(&models.Leader{}, c.Params("id"))
+	if result.Error != nil {
+		return c.Status(500).JSON(fiber.Map{"error": result.Error.Error()})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Leader not found"})
+	}
+	return c.SendStatus(204)
+}
+
+func applyLeaderRequest(leader *models.Leader, req LeaderRequest) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "name is required"
+	}
+	if req.PartyID != nil && *req.PartyID != 0 {
+		var party models.Party
+		if db.DB.First(&party, *req.PartyID).Error != nil {
+			return "party_id must be an existing party"
+		}
+	}
+	var clash models.Leader
+	if db.DB.Where("LOWER(name) = LOWER(?) AND id <> ?", req.Name, leader.ID).First(&clash).Error == nil {
+		return "A leader with that name already exists"
+	}
+
+	var aliases models.StringList
+	seen := map[string]bool{strings.ToLower(req.Name): true}
+	for _, a := range req.Aliases {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[strings.ToLower(a)] {
+			continue
+		}
+		seen[strings.ToLower(a)] = true
+		aliases = append(aliases, a)
+	}
+
+	leader.Name = req.Name
+	leader.Aliases = aliases
+	leader.PartyID = req.PartyID
+	if req.PartyID != nil && *req.PartyID == 0 {
+		leader.PartyID = nil
+	}
+	if req.Active != nil {
+		leader.Active = *req.Active
+	}
+	return ""
+}
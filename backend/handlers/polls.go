@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetPolls lists imported opinion polls, newest first. ?limit= is clamped
+// to 1..500.
+func GetPolls(c *fiber.Ctx) error {
+	limit := min(max(c.QueryInt("limit", 100), 1), 500)
+	q := db.DB.Preload("Shares").Order("date desc, pollster")
+	if pollster := c.Query("pollster"); pollster != "" {
+		q = q.Where("pollster = ?", pollster)
+	}
+	var polls []models.Poll
+	if err := q.Limit(limit).Find(&polls).Error; err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(polls)
+}
+
+// calibratedParty is a party's latest raw score next to its calibrated share.
+type calibratedParty struct {
+	PartyID    uint                      `json:"party_id"`
+	Name       string                    `json:"name"`
+	Score      float64                   `json:"sentiment_score"`
+	Calibrated *services.CalibratedShare `json:"calibrated"`
+}
+
+// GetCalibration reports the sentiment-to-vote-share fit and applies it to
+// each party's latest score.
+func GetCalibration(c *fiber.Ctx) error {
+	cal, err := services.CurrentCalibration()
+	if errors.Is(err, services.ErrNotCalibrated) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error() + "; import polls with POST /admin/polls/import"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var parties []models.Party
+	db.DB.Where("active = ?", true).Order("id").Find(&parties)
+	out := make([]calibratedParty, 0, len(parties))
+	for _, p := range parties {
+		var snap models.SentimentSnapshot
+		if db.DB.Where("party_id = ?", p.ID).Order("created_at desc").First(&snap).Error != nil {
+			continue
+		}
+		est := cal.Estimate(snap.Score)
+		out = append(out, calibratedParty{PartyID: p.ID, Name: p.Name, Score: snap.Score, Calibrated: &est})
+	}
+
+	resp := fiber.Map{"fit": cal, "parties": out}
+	if !c.QueryBool("points") {
+		fit := *cal
+		fit.Points = nil
+		resp["fit"] = fit
+	}
+	return c.JSON(resp)
+}
+
+// ImportPolls loads opinion polls from a JSON array or a CSV body, picked by
+// Content-Type.
+func ImportPolls(c *fiber.Ctx) error {
+	var (
+		polls []services.PollInput
+		err   error
+	)
+	if strings.Contains(c.Get(fiber.HeaderContentType), "json") {
+		polls, err = services.ParsePollsJSON(bytes.NewReader(c.Body()))
+	} else {
+		polls, err = services.ParsePollsCSV(bytes.NewReader(c.Body()))
+	}
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	summary, err := services.ImportPolls(polls)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(summary)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"election-pulse-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxPublicRuns caps Monte Carlo runs on the public endpoint. cmd/backtest
+// calls the service directly and isn't limited.
+const maxPublicRuns = 5000
+
+// GetProjections turns the latest sentiment movement into seat ranges.
+func GetProjections(c *fiber.Ctx) error {
+	opts := services.ProjectionOptions{
+		BaseYear:     c.QueryInt("base_year", 0),
+		Method:       c.Query("method", services.SwingUniform),
+		Runs:         c.QueryInt("runs", 2000),
+		RecentDays:   c.QueryInt("recent_days", 7),
+		BaselineDays: c.QueryInt("baseline_days", 30),
+		Seats:        c.QueryBool("constituencies"),
+	}
+	if opts.Method != services.SwingUniform && opts.Method != services.SwingProportional {
+		return c.Status(400).JSON(fiber.Map{"error": "method must be uniform or proportional"})
+	}
+	if opts.Runs < 1 || opts.Runs > maxPublicRuns {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("runs must be between 1 and %d", maxPublicRuns)})
+	}
+	if opts.RecentDays < 1 || opts.BaselineDays < 1 || opts.RecentDays+opts.BaselineDays > 365 {
+		return c.Status(400).JSON(fiber.Map{"error": "recent_days and baseline_days must be positive and add up to at most 365"})
+	}
+	if seed := c.Query("seed"); seed != "" {
+		n, err := strconv.ParseUint(seed, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "seed must be a positive integer"})
+		}
+		opts.Seed = n
+	}
+
+	proj, err := services.ProjectCached(opts)
+	if errors.Is(err, services.ErrNoResults) {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error() + "; import them with POST /admin/results/import"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(proj)
+}
+
+// ImportResults loads a past election's constituency results from a CSV body.
+func ImportResults(c *fiber.Ctx) error {
+	year := c.QueryInt("year")
+	if year == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "year is required, e.g. ?year=2021"})
+	}
+	summary, err := services.ImportResults(bytes.NewReader(c.Body()), year)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(summary)
+}
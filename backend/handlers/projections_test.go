@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Only requests that fail validation are tested here; the rest need a
+// database.
+func TestGetProjectionsValidation(t *testing.T) {
+	app := fiber.New()
+	app.Get("/projections", GetProjections)
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"method=gaussian", 400},
+		{"runs=0", 400},
+		{"runs=5001", 400},
+		{"runs=20000", 400},
+		{"recent_days=0", 400},
+		{"recent_days=300&baseline_days=100", 400},
+		{"seed=-1", 400},
+		{"seed=abc", 400},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest("GET", "/projections?"+tt.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.query, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"election-pulse-backend/services"
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,18 +22,50 @@ func SetupRoutes(app *fiber.App) {
 	api.Get("/latest", GetLatestSnapshot)
 	api.Get("/history/:party_id", GetHistory)
 	api.Get("/trends", GetTrends)
+	api.Get("/quota", GetQuota)
+	api.Get("/sources/status", GetSourceStatus)
+	api.Get("/compare", CompareParties)
+	api.Post("/compare/joint", CompareJoint)
+	api.Get("/leaderboard", GetLeaderboard)
+	api.Get("/projections", GetProjections)
+	api.Get("/polls", GetPolls)
+	api.Get("/calibration", GetCalibration)
+	setupAllianceRoutes(api)
+	setupLeaderRoutes(api)
+
+	setupAdminRoutes(api)
 }
 
 func GetParties(c *fiber.Ctx) error {
 	var parties []models.Party
-	if result := db.DB.Find(&parties); result.Error != nil {
+	q := db.DB.Order("id")
+	if !c.QueryBool("all") {
+		q = q.Where("active = ?", true)
+	}
+	if result := q.Find(&parties); result.Error != nil {
 		return c.Status(500).JSON(fiber.Map{"error": result.Error.Error()})
 	}
 	return c.JSON(parties)
 }
 
+// AnalyzeRequest names a party by ID, name or alias, or asks for an ad-hoc
+// topic analysis that isn't stored.
 type AnalyzeRequest struct {
+	PartyID   uint   `json:"party_id"`
 	PartyName string `json:"party_name"`
+	Topic     string `json:"topic"`
+}
+
+type AnalyzeResponse struct {
+	*services.AIAnalysisResult
+	SuspectedInorganicShare float64                    `json:"suspected_inorganic_share"`
+	Inauthentic             services.InauthenticReport `json:"inauthentic"`
+	Degraded                bool                       `json:"degraded"`
+	Coverage                services.Coverage          `json:"coverage"`
+	Sources                 []services.SourceResult    `json:"sources"`
+	Shared                  bool                       `json:"shared"` // Joined a run another request had already started
+	PartyID                 uint                       `json:"party_id,omitempty"`
+	Topic                   string                     `json:"topic,omitempty"` // Set in topic mode, which saves no snapshot
 }
 
 func AnalyzeParty(c *fiber.Ctx) error {
@@ -39,89 +74,69 @@ func AnalyzeParty(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
-	// 1. Fetch Data
-	data, err := services.FetchAllData(c.Context(), req.PartyName)
+	var run *services.AnalysisRun
+	var err error
+	if req.PartyID == 0 && req.PartyName == "" {
+		if strings.TrimSpace(req.Topic) == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "party_id, party_name or topic is required"})
+		}
+		run, err = services.AnalyzeTopic(c.UserContext(), req.Topic, analyzeTimeout())
+	} else {
+		// Resolve before fetching so unknown parties cost no quota
+		party, perr := resolveParty(req.PartyID, req.PartyName)
+		if perr != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Party not found"})
+		}
+		// Concurrent requests for the same party share one run and one snapshot
+		run, err = services.AnalyzeParty(c.UserContext(), party, analyzeTimeout())
+	}
 	if err != nil {
-		fmt.Printf("Error fetching data: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch data: " + err.Error()})
+		fmt.Printf("Error analyzing %v: %v\n", req, err)
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// 2. Prepare text for AI
-	var corpus string
-	corpus += "Latest News Headlines:\n"
-	for _, n := range data.News {
-		corpus += "- " + n.Title + "\n"
+	data := run.Data
+	resp := AnalyzeResponse{
+		AIAnalysisResult:        run.Analysis,
+		SuspectedInorganicShare: data.Inauthentic.InorganicShare,
+		Inauthentic:             data.Inauthentic,
+		Degraded:                data.Coverage.Degraded,
+		Coverage:                data.Coverage,
+		Sources:                 data.Sources,
+		Shared:                  run.Shared,
+		Topic:                   req.Topic,
 	}
-	corpus += "\nSocial Media Comments:\n"
-	for _, c := range data.Comments {
-		corpus += "- " + c.Text + "\n"
+	if run.Snapshot != nil {
+		resp.PartyID = run.Snapshot.PartyID
 	}
+	return c.JSON(resp)
+}
 
-	corpus += "\nReddit Discussions:\n"
-	for _, p := range data.RedditPosts {
-		corpus += fmt.Sprintf("- Title: %s\n  Body: %s\n", p.Title, p.Text)
+// resolveParty looks a party up by ID, or else by name or alias.
+func resolveParty(id uint, name string) (models.Party, error) {
+	if id != 0 {
+		return services.ResolveParty(strconv.FormatUint(uint64(id), 10))
 	}
+	return services.ResolveParty(name)
+}
 
-	fmt.Printf("Corpus prepared: %d news, %d comments, %d reddit posts\n", len(data.News), len(data.Comments), len(data.RedditPosts))
-
-	// 3. Analyze with AI
-	analysis, err := services.AnalyzeSentiment(c.Context(), corpus)
-	if err != nil {
-		fmt.Printf("Error analyzing sentiment: %v\n", err)
-		return c.Status(500).JSON(fiber.Map{"error": "AI Analysis failed: " + err.Error()})
-	}
-
-	// 4. Save Snapshot (Optional: Find Party ID first)
-	var party models.Party
-	db.DB.Where("name = ?", req.PartyName).First(&party)
-	if party.ID != 0 {
-		// Marshal KeyTopics to JSON string
-		keyTopicsJSON, _ := json.Marshal(analysis.KeyTopics)
-
-		snapshot := models.SentimentSnapshot{
-			PartyID: party.ID,
-			Score:   analysis.SentimentScore * 100, // Convert -1.0-1.0 to 0-100 if needed, or mapping logic?
-			// The plan said: WinningProbability = 50 + (RawScore * 50)
-			// RawScore assumed to be -1 to 1.
-			// Let's apply: 50 + (analysis.SentimentScore * 50)
-			// Wait, the previous prompt Plan said: 0-100 score.
-			// Let's assume the AI returns -1.0 to 1.0.
-			KeyTopics:       string(keyTopicsJSON),
-			Emotion:         analysis.Emotion,
-			SourceBreakdown: "{}", // simplification
-			CreatedAt:       time.Now(),
-		}
-		// Adjust score logic
-		rawScore := analysis.SentimentScore
-		// Clamp between -1 and 1 just in case
-		if rawScore > 1 {
-			rawScore = 1
-		}
-		if rawScore < -1 {
-			rawScore = -1
-		}
-
-		finalScore := 50 + (rawScore * 50)
-		snapshot.Score = finalScore
-		// snapshot.KeyIssue = safeGetTopic(analysis.KeyTopics) // Removed
-
-		db.DB.Create(&snapshot)
-
-		// Return result with the calculated score
-		analysis.SentimentScore = finalScore
+// analyzeTimeout bounds a whole /analyze run, including the AI call.
+func analyzeTimeout() time.Duration {
+	if d, err := time.ParseDuration(os.Getenv("ANALYZE_TIMEOUT")); err == nil {
+		return d
 	}
-
-	return c.JSON(analysis)
+	return 90 * time.Second
 }
 
 func GetLatestSnapshot(c *fiber.Ctx) error {
 	partyName := c.Query("party_name")
-	if partyName == "" {
-		return c.Status(400).JSON(fiber.Map{"error": "Party Name is required"})
+	partyID := c.QueryInt("party_id")
+	if partyName == "" && partyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "party_id or party_name is required"})
 	}
 
-	var party models.Party
-	if err := db.DB.Where("name = ?", partyName).First(&party).Error; err != nil {
+	party, err := resolveParty(uint(max(partyID, 0)), partyName)
+	if err != nil {
 		return c.Status(404).JSON(fiber.Map{"error": "Party not found"})
 	}
 
@@ -140,6 +155,17 @@ func GetLatestSnapshot(c *fiber.Ctx) error {
 		json.Unmarshal([]byte(snapshot.KeyTopics), &keyTopics)
 	}
 
+	// Older snapshots stored "{}" here
+	var breakdown services.SourceBreakdown
+	json.Unmarshal([]byte(snapshot.SourceBreakdown), &breakdown)
+
+	// Calibrated vote share, once enough polls are in
+	var calibrated *services.CalibratedShare
+	if cal, err := services.CurrentCalibration(); err == nil {
+		est := cal.Estimate(snapshot.Score)
+		calibrated = &est
+	}
+
 	// Map DB snapshot to response format matching AnalyzeParty
 	return c.JSON(fiber.Map{
 		"exists":          true,
@@ -147,6 +173,13 @@ func GetLatestSnapshot(c *fiber.Ctx) error {
 		"key_topics":      keyTopics,
 		"emotion":         snapshot.Emotion,
 		"created_at":      snapshot.CreatedAt,
+		"created_at_ist":  services.FormatIST(snapshot.CreatedAt),
+
+		"suspected_inorganic_share": snapshot.InorganicShare,
+		"degraded":                  snapshot.Degraded,
+		"coverage":                  breakdown.Coverage,
+		"sources":                   breakdown.Sources,
+		"calibrated":                calibrated,
 	})
 }
 
@@ -162,10 +195,37 @@ func GetHistory(c *fiber.Ctx) error {
 }
 
 func GetTrends(c *fiber.Ctx) error {
-	trends, err := services.FetchTrends(c.Context())
+	trends, err := services.FetchTrends(c.UserContext())
 	if err != nil {
 		fmt.Printf("Error fetching trends: %v\n", err)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to fetch trends: " + err.Error()})
 	}
 	return c.JSON(trends)
 }
+
+func GetQuota(c *fiber.Ctx) error {
+	return c.JSON([]services.QuotaStatus{
+		services.YouTubeQuota.Status(),
+		services.NewsDataCredits.Status(),
+	})
+}
+
+// GetLeaderboard ranks every active party with deltas, momentum and a
+// sparkline. ?sort=momentum orders by momentum instead of score.
+func GetLeaderboard(c *fiber.Ctx) error {
+	sortBy := c.Query("sort", "score")
+	if sortBy != "score" && sortBy != "momentum" {
+		return c.Status(400).JSON(fiber.Map{"error": "sort must be score or momentum"})
+	}
+	board, err := services.BuildLeaderboard(c.UserContext(), sortBy)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(board)
+}
+
+// GetSourceStatus reports the circuit breaker of every source contacted since
+// startup. Open breakers are skipped until their retry_at.
+func GetSourceStatus(c *fiber.Ctx) error {
+	return c.JSON(services.BreakerStatuses())
+}
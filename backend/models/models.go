@@ -9,11 +9,44 @@ import (
 type Party struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
 	Name      string         `json:"name"`
+	Aliases   StringList     `gorm:"type:jsonb;default:'[]'" json:"aliases"` // Other names the API accepts, e.g. "Dravida Munnetra Kazhagam"
 	Leader    string         `json:"leader"`
 	ColorHex  string         `json:"color_hex"`
+	LogoURL   string         `json:"logo_url"`
+	Active    bool           `gorm:"default:true" json:"active"` // Inactive parties are hidden from the dashboard
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Memberships []AllianceMembership `gorm:"foreignKey:PartyID" json:"memberships,omitempty"`
+}
+
+// Alliance is a pre-poll coalition such as the DMK-led Secular Progressive
+// Alliance.
+type Alliance struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	ColorHex    string    `json:"color_hex"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Memberships []AllianceMembership `gorm:"foreignKey:AllianceID" json:"memberships,omitempty"`
+}
+
+// AllianceMembership records a party being in an alliance for a period.
+// Until is nil while the membership is current; parties switch sides often
+// enough that history matters.
+type AllianceMembership struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	AllianceID uint       `gorm:"index;not null" json:"alliance_id"`
+	Alliance   *Alliance  `gorm:"foreignKey:AllianceID" json:"alliance,omitempty"`
+	PartyID    uint       `gorm:"index;not null" json:"party_id"`
+	Since      time.Time  `json:"since"`
+	Until      *time.Time `json:"until"`
+	Seats      int        `json:"seats"`      // Seats the party contests for the alliance
+	VoteShare  float64    `json:"vote_share"` // Statewide vote share at the last election, in percent
+	CreatedAt  time.Time  `json:"created_at"`
 }
 
 type SentimentSnapshot struct {
@@ -23,6 +56,179 @@ type SentimentSnapshot struct {
 	Score           float64   `json:"score"`
 	KeyTopics       string    `gorm:"type:jsonb" json:"key_topics"` // Stores JSON array of strings
 	Emotion         string    `json:"emotion"`
-	SourceBreakdown string    `gorm:"type:jsonb" json:"source_breakdown"`
+	FactCheckNotes  string    `gorm:"type:text" json:"fact_check_notes"`
+	SourceBreakdown string    `gorm:"type:jsonb" json:"source_breakdown"` // Per-source results and coverage of the run
+	InorganicShare  float64   `json:"suspected_inorganic_share"`          // Share of social items flagged as coordinated, 0-1
+	Degraded        bool      `json:"degraded"`                           // Below the coverage thresholds, see SourceBreakdown
+	Backfilled      bool      `json:"backfilled"`                         // Rebuilt from archives rather than a live run
+	Volume          int       `json:"volume"`                             // News and social items the score was based on
 	CreatedAt       time.Time `json:"created_at"`
 }
+
+// Leader is a politician tracked separately from their party, since the mood
+// towards a leader often differs from the mood towards the party.
+type Leader struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Name      string     `gorm:"uniqueIndex;not null" json:"name"`
+	Aliases   StringList `gorm:"type:jsonb;default:'[]'" json:"aliases"` // Other names documents use, e.g. "EPS"
+	PartyID   *uint      `gorm:"index" json:"party_id"`
+	Active    bool       `gorm:"default:true" json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// LeaderSnapshot is a leader's personal rating from one analysis run. A run
+// for any party can rate any leader its documents mention, so PartyID is the
+// party that was analysed, not necessarily the leader's own.
+type LeaderSnapshot struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	LeaderID   uint      `gorm:"index;not null" json:"leader_id"`
+	PartyID    uint      `json:"party_id"`
+	SnapshotID *uint     `gorm:"index" json:"snapshot_id"` // The party snapshot from the same run
+	Score      float64   `json:"score"`                    // 0-100, same scale as party snapshots
+	Emotion    string    `json:"emotion"`
+	Mentions   int       `json:"mentions"` // Documents in the run that mention the leader
+	Backfilled bool      `json:"backfilled"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// WatchedChannel is a YouTube channel whose latest uploads are always sampled.
+// Party and leader channels belong to one party; news channels have no
+// PartyID and are matched against the party name in video titles.
+type WatchedChannel struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	PartyID           *uint     `gorm:"index" json:"party_id"`
+	ChannelID         string    `gorm:"index;not null" json:"channel_id"`
+	Name              string    `json:"name"`
+	Kind              string    `json:"kind"`                // "party", "leader" or "news"
+	UploadsPlaylistID string    `json:"uploads_playlist_id"` // Cached from channels.list
+	Active            bool      `gorm:"default:true" json:"active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// QuotaUsage is a daily quota ledger row per provider and API operation.
+// Day is the provider's quota day (Pacific time for YouTube), not UTC.
+type QuotaUsage struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	Service   string    `gorm:"uniqueIndex:idx_quota_usage_key;not null" json:"service"`
+	Day       string    `gorm:"uniqueIndex:idx_quota_usage_key;not null" json:"day"` // YYYY-MM-DD
+	Operation string    `gorm:"uniqueIndex:idx_quota_usage_key;not null" json:"operation"`
+	Units     int       `json:"units"`
+	Calls     int       `json:"calls"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SourceTarget is a place we pull documents from: a subreddit, an RSS feed or
+// a news domain. Targets apply globally, to one party or to one region.
+// RSS values may contain a {query} placeholder for the party name.
+type SourceTarget struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Kind      string    `gorm:"index;not null" json:"kind"` // "subreddit", "rss" or "news_domain"
+	Value     string    `gorm:"not null" json:"value"`
+	Label     string    `json:"label"`
+	Scope     string    `gorm:"not null;default:global" json:"scope"` // "global", "party" or "region"
+	PartyID   *uint     `gorm:"index" json:"party_id,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	Active    bool      `gorm:"default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Feed is the fetch state and health of one RSS feed URL. Templated targets
+// expand to one feed per query.
+type Feed struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	TargetID            uint       `gorm:"index" json:"target_id"`
+	URL                 string     `gorm:"uniqueIndex;not null" json:"url"`
+	Source              string     `json:"source"`
+	ETag                string     `json:"etag"`
+	LastModified        string     `json:"last_modified"`
+	LastFetchedAt       *time.Time `json:"last_fetched_at"`
+	LastSuccessAt       *time.Time `json:"last_success_at"`
+	LastError           string     `json:"last_error"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	AvgLatencyMs        float64    `json:"avg_latency_ms"` // Moving average over recent fetches
+	Disabled            bool       `gorm:"index" json:"disabled"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// FeedItem is an RSS item we have already ingested, keyed by feed and GUID.
+type FeedItem struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	FeedID      uint       `gorm:"uniqueIndex:idx_feed_items_guid;not null" json:"feed_id"`
+	GUID        string     `gorm:"uniqueIndex:idx_feed_items_guid;not null" json:"guid"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	Description string     `gorm:"type:text" json:"description"`
+	Content     string     `gorm:"type:text" json:"content"`
+	Body        string     `gorm:"type:text" json:"body"`     // Extracted plain-text article body
+	PublishedAt *time.Time `gorm:"index" json:"published_at"` // UTC, NULL when the feed gave no usable date
+	FetchedAt   time.Time  `json:"fetched_at"`
+}
+
+// BackfillBucket records the outcome of one backfill time bucket for a party,
+// so interrupted runs resume where they stopped and reruns skip finished work.
+type BackfillBucket struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	PartyID     uint      `gorm:"uniqueIndex:idx_backfill_bucket;not null" json:"party_id"`
+	BucketStart time.Time `gorm:"uniqueIndex:idx_backfill_bucket;not null" json:"bucket_start"`
+	BucketEnd   time.Time `json:"bucket_end"`
+	Status      string    `json:"status"` // "done", "partial", "empty" or "failed"
+	SnapshotID  *uint     `json:"snapshot_id"`
+	Items       int       `json:"items"`
+	Error       string    `json:"error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Constituency is one of the 234 Tamil Nadu assembly seats.
+type Constituency struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Number    int       `gorm:"uniqueIndex;not null" json:"number"` // ECI assembly constituency number
+	Name      string    `gorm:"not null" json:"name"`
+	District  string    `json:"district"`
+	Region    string    `json:"region"`   // e.g. "Kongu", "Delta"; free text
+	Reserved  string    `json:"reserved"` // "SC", "ST" or "" for general seats
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ElectionResult is one party's (or independent's) votes in a constituency at
+// a past assembly election. PartyID is nil for parties we don't track; they
+// count as "Others" in projections.
+type ElectionResult struct {
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	Year           int     `gorm:"uniqueIndex:idx_election_result;not null" json:"year"`
+	ConstituencyID uint    `gorm:"uniqueIndex:idx_election_result;not null" json:"constituency_id"`
+	PartyName      string  `gorm:"uniqueIndex:idx_election_result;not null" json:"party_name"` // As written in the source data
+	PartyID        *uint   `gorm:"index" json:"party_id"`
+	Candidate      string  `json:"candidate"`
+	Votes          int     `json:"votes"`
+	VoteShare      float64 `json:"vote_share"` // Percent of valid votes in the constituency
+	Won            bool    `json:"won"`
+}
+
+// Poll is a published opinion poll. Date is the last day of fieldwork, or the
+// publication date when the pollster doesn't give one.
+type Poll struct {
+	ID         uint        `gorm:"primaryKey" json:"id"`
+	Pollster   string      `gorm:"uniqueIndex:idx_poll_key;not null" json:"pollster"`
+	Date       time.Time   `gorm:"uniqueIndex:idx_poll_key;not null" json:"date"`
+	SampleSize int         `json:"sample_size"`
+	SourceURL  string      `json:"source_url"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Shares     []PollShare `gorm:"foreignKey:PollID" json:"shares"`
+}
+
+// PollShare is one party's vote share in a poll, in percent. PartyID is nil
+// for parties we don't track.
+type PollShare struct {
+	ID        uint    `gorm:"primaryKey" json:"-"`
+	PollID    uint    `gorm:"index;not null" json:"-"`
+	PartyName string  `json:"party_name"`
+	PartyID   *uint   `gorm:"index" json:"party_id"`
+	Share     float64 `json:"share"`
+}
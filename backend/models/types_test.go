@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringListValue(t *testing.T) {
+	tests := []struct {
+		list StringList
+		want string
+	}{
+		{nil, "[]"},
+		{StringList{}, "[]"},
+		{StringList{"EPS", "எடப்பாடி"}, `["EPS","எடப்பாடி"]`},
+	}
+	for _, tt := range tests {
+		got, err := tt.list.Value()
+		if err != nil || got != tt.want {
+			t.Errorf("%#v.Value() = %v, %v; want %s", tt.list, got, err, tt.want)
+		}
+	}
+}
+
+func TestStringListScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    StringList
+		wantErr bool
+	}{
+		{nil, nil, false},
+		{[]byte(`["DMK","Dravida Munnetra Kazhagam"]`), StringList{"DMK", "Dravida Munnetra Kazhagam"}, false},
+		{`["திமுக"]`, StringList{"திமுக"}, false},
+		{`[]`, StringList{}, false},
+		{42, nil, true},
+		{`{"not": "a list"}`, nil, true},
+	}
+	for _, tt := range tests {
+		var got StringList
+		err := got.Scan(tt.src)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Scan(%v): err = %v, want error %v", tt.src, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan(%v) = %#v, want %#v", tt.src, got, tt.want)
+		}
+	}
+}
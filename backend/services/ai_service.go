@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/google/generative-ai-go/genai"
@@ -16,32 +15,20 @@ type AIAnalysisResult struct {
 	Emotion        string   `json:"emotion"`
 	KeyTopics      []string `json:"key_topics"`
 	FactCheckNotes string   `json:"fact_check_notes"`
-}
-
-func AnalyzeSentiment(ctx context.Context, textData string) (*AIAnalysisResult, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("GEMINI_API_KEY is not set")
-	}
-
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gemini client: %w", err)
-	}
-	defer client.Close()
 
-	// Try gemini-1.5-flash-001 first, then fallback to gemini-pro if needed
-	modelName := "gemini-2.5-flash"
-	model := client.GenerativeModel(modelName)
-	model.ResponseMIMEType = "application/json"
+	LeaderScores []LeaderScore `json:"leader_scores"` // Only leaders the corpus mentions often enough
+}
 
+// AnalyzeSentiment scores the corpus. leaders are the people to rate on their
+// own, apart from the party; pass none to skip leader scoring.
+func AnalyzeSentiment(ctx context.Context, textData string, leaders []string) (*AIAnalysisResult, error) {
 	prompt := fmt.Sprintf(`
 You are an expert political analyst and social psychologist specializing in Tamil Nadu politics. 
-Your task is to analyze the provided text data (Headlines, social media comments, Reddit discussions) regarding a political party.
+Your task is to analyze the provided text data (Headlines with article text, social media comments, Reddit discussions) regarding a political party.
 
 ### PHASE 1: THINKING PROCESS
 Before generating the JSON, perform a deep analysis (you can output this thought process before the JSON block):
-1. **Source Weighting**: Prioritize reputable news (e.g., BBC, Hindustan Times, Dinamalar) over unverified social media noise.
+1. **Source Weighting**: Prioritize reputable news (e.g., BBC, Hindustan Times, Dinamalar) over unverified social media noise. Items prefixed with "[low-trust]" were flagged as possibly coordinated/bot activity; give them little weight.
 2. **Bias Detection**: specific political biases in the source text and neutralize them.
 3. **Contextual nuance**: Differentiate between "Mockery" (trolling) and genuine "Anger". Understand TN political slang (e.g., 'Sanghi', 'Upee', 'Dravidiya Model').
 4. **Aggregate Scoring**: Calculate the score based on the *weighted* evidence, not just the volume of text.
@@ -52,33 +39,67 @@ Output strictly a valid JSON object.
 - **Emotion**: MUST be exactly one of these: "Strong Support", "Support", "Neutral", "Disappointment", "Anger", "Hope", "Fear", "Mockery".
 - **Key Topics**: Top 3-5 specific themes driving this sentiment.
 - **Fact Check**: Note any identified misinformation or "None".
-
+%s
 JSON Schema:
 {
   "sentiment_score": float,
   "emotion": string,
   "key_topics": [string],
-  "fact_check_notes": string
+  "fact_check_notes": string,
+  "leader_scores": [{"name": string, "sentiment_score": float, "emotion": string}]
 }
 
 Data to Analyze:
 %s
-`, textData)
+`, leaderInstructions(leaders), textData)
+
+	var result AIAnalysisResult
+	if err := generateJSON(ctx, prompt, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// generateJSON sends prompt to Gemini and decodes the JSON object in the
+// reply into out. The model may think out loud before the JSON, so the
+// outermost braces are cut out first.
+func generateJSON(ctx context.Context, prompt string, out interface{}) error {
+	apiKey := apiKeyFromEnv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return fmt.Errorf("GEMINI_API_KEY is not set")
+	}
+
+	// Route Gemini through the shared transport so it can be recorded too.
+	// The REST calls get the key from the transport; WithAPIKey is still
+	// needed because the SDK builds its cache client without our HTTP client
+	// and would otherwise look for default credentials.
+	httpClient := newHTTPClient(0)
+	httpClient.Transport = &apiKeyTransport{key: apiKey, next: httpClient.Transport}
+	client, err := genai.NewClient(ctx, option.WithHTTPClient(httpClient), option.WithAPIKey(apiKey))
+	if err != nil {
+		return fmt.Errorf("failed to create gemini client: %w", err)
+	}
+	defer client.Close()
+
+	// Try gemini-1.5-flash-001 first, then fallback to gemini-pro if needed
+	modelName := "gemini-2.5-flash"
+	model := client.GenerativeModel(modelName)
+	model.ResponseMIMEType = "application/json"
 
 	resp, err := model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
-		return nil, fmt.Errorf("gemini generation failed: %w", err)
+		return fmt.Errorf("gemini generation failed: %w", err)
 	}
 
 	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("no response from AI")
+		return fmt.Errorf("no response from AI")
 	}
 
 	// Extract text
 	part := resp.Candidates[0].Content.Parts[0]
 	txt, ok := part.(genai.Text)
 	if !ok {
-		return nil, fmt.Errorf("unexpected response format")
+		return fmt.Errorf("unexpected response format")
 	}
 
 	// Extract JSON from response (robust method)
@@ -88,15 +109,24 @@ Data to Analyze:
 	end := strings.LastIndex(rawText, "}")
 
 	if start == -1 || end == -1 || start > end {
-		return nil, fmt.Errorf("invalid response format: could not find JSON object. Raw: %s", rawText)
+		return fmt.Errorf("invalid response format: could not find JSON object. Raw: %s", rawText)
 	}
 
 	jsonStr := rawText[start : end+1]
 
-	var result AIAnalysisResult
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
-		return nil, fmt.Errorf("failed to parse AI JSON response: %w. Raw: %s", err, jsonStr)
+	if err := json.Unmarshal([]byte(jsonStr), out); err != nil {
+		return fmt.Errorf("failed to parse AI JSON response: %w. Raw: %s", err, jsonStr)
 	}
 
-	return &result, nil
+	return nil
+}
+
+// leaderInstructions asks for personal ratings of the given leaders, judged
+// only on the items that mention them.
+func leaderInstructions(leaders []string) string {
+	if len(leaders) == 0 {
+		return "- **Leader Scores**: Return an empty list.\n"
+	}
+	return fmt.Sprintf(`- **Leader Scores**: For each of these people, rate public sentiment toward them personally, using only the items that mention them (by name, nickname or Tamil spelling). Judge the person, not their party: people often like a leader while disliking the party, or the other way round. Use the same -1.0 to 1.0 scale and emotion labels, and copy each name exactly as written here: %s.
+`, strings.Join(leaders, "; "))
 }
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+// Alliance weighting modes
+const (
+	WeightEqual = "equal" // Every member counts the same
+	WeightSeats = "seats" // By seats contested for the alliance
+	WeightVotes = "votes" // By vote share at the last election
+)
+
+// DefaultWeighting is the weighting used when a request doesn't pick one.
+func DefaultWeighting() string {
+	if w := os.Getenv("ALLIANCE_WEIGHTING"); ValidWeighting(w) {
+		return w
+	}
+	return WeightSeats
+}
+
+func ValidWeighting(w string) bool {
+	return w == WeightEqual || w == WeightSeats || w == WeightVotes
+}
+
+// MemberScore is one party's contribution to an alliance data point.
+type MemberScore struct {
+	PartyID uint    `json:"party_id"`
+	Score   float64 `json:"score"`
+	Weight  float64 `json:"weight"` // Normalised over the members with data that day
+}
+
+// AlliancePoint is the alliance's aggregate sentiment for one day.
+type AlliancePoint struct {
+	Date     string        `json:"date"` // YYYY-MM-DD, IST
+	Score    float64       `json:"score"`
+	Coverage float64       `json:"coverage"` // Share of the alliance's weight that had a snapshot that day
+	Members  []MemberScore `json:"members"`
+}
+
+type AllianceSeries struct {
+	AllianceID uint            `json:"alliance_id"`
+	Name       string          `json:"name"`
+	ColorHex   string          `json:"color_hex"`
+	Weighting  string          `json:"weighting"`
+	Points     []AlliancePoint `json:"points"`
+}
+
+// memberWeight is a membership's raw weight under a weighting mode.
+func memberWeight(m models.AllianceMembership, weighting string) float64 {
+	switch weighting {
+	case WeightSeats:
+		return float64(m.Seats)
+	case WeightVotes:
+		return m.VoteShare
+	}
+	return 1
+}
+
+// memberWeights gives each membership its weight, in the same order. A member
+// without seats or a vote share (e.g. one added by a party edit) gets the mean
+// of the members that have one, so it counts as a typical member instead of
+// dropping out. ok is false when no member has a weight under the mode.
+func memberWeights(memberships []models.AllianceMembership, weighting string) (weights []float64, ok bool) {
+	weights = make([]float64, len(memberships))
+	known, total := 0, 0.0
+	for i, m := range memberships {
+		weights[i] = memberWeight(m, weighting)
+		if weights[i] > 0 {
+			known++
+			total += weights[i]
+		}
+	}
+	if known == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		return weights, false
+	}
+	for i := range weights {
+		if weights[i] <= 0 {
+			weights[i] = total / float64(known)
+		}
+	}
+	return weights, true
+}
+
+// activeOn reports whether the membership covers the IST day starting at day.
+func activeOn(m models.AllianceMembership, day time.Time) bool {
+	end := day.Add(24 * time.Hour)
+	return m.Since.Before(end) && (m.Until == nil || m.Until.After(day))
+}
+
+// AllianceHistory aggregates member snapshots into one daily series between
+// from and to. Each member's score for a day is the mean of its snapshots
+// that day (IST), and the alliance score is the weighted mean over members
+// that belonged to the alliance on that day and have data. Days without any
+// member data are left out.
+func AllianceHistory(allianceID uint, from, to time.Time, weighting string) (*AllianceSeries, error) {
+	var alliance models.Alliance
+	if err := db.DB.Preload("Memberships").First(&alliance, allianceID).Error; err != nil {
+		return nil, fmt.Errorf("alliance %d: %w", allianceID, err)
+	}
+	series := &AllianceSeries{
+		AllianceID: alliance.ID,
+		Name:       alliance.Name,
+		ColorHex:   alliance.ColorHex,
+		Weighting:  weighting,
+		Points:     []AlliancePoint{},
+	}
+	if len(alliance.Memberships) == 0 {
+		return series, nil
+	}
+
+	// If the chosen weighting has no data at all, fall back to equal weights
+	// rather than returning nothing
+	weights, ok := memberWeights(alliance.Memberships, weighting)
+	if !ok {
+		series.Weighting = WeightEqual
+	}
+
+	partyIDs := make([]uint, 0, len(alliance.Memberships))
+	for _, m := range alliance.Memberships {
+		partyIDs = append(partyIDs, m.PartyID)
+	}
+
+	var snapshots []models.SentimentSnapshot
+	err := db.DB.Select("party_id", "score", "created_at").
+		Where("party_id IN ? AND created_at >= ? AND created_at < ?", partyIDs, from.UTC(), to.UTC()).
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// day -> party -> scores
+	type acc struct {
+		sum float64
+		n   int
+	}
+	byDay := make(map[string]map[uint]*acc)
+	for _, s := range snapshots {
+		day := s.CreatedAt.In(IST).Format("2006-01-02")
+		if byDay[day] == nil {
+			byDay[day] = make(map[uint]*acc)
+		}
+		a := byDay[day][s.PartyID]
+		if a == nil {
+			a = &acc{}
+			byDay[day][s.PartyID] = a
+		}
+		a.sum += s.Score
+		a.n++
+	}
+
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	for _, day := range days {
+		start, _ := time.ParseInLocation("2006-01-02", day, IST)
+		var point AlliancePoint
+		point.Date = day
+		dayWeight, coveredWeight, weighted := 0.0, 0.0, 0.0
+
+		for i, m := range alliance.Memberships {
+			if !activeOn(m, start) {
+				continue
+			}
+			w := weights[i]
+			dayWeight += w
+			a := byDay[day][m.PartyID]
+			if a == nil {
+				continue
+			}
+			score := a.sum / float64(a.n)
+			coveredWeight += w
+			weighted += w * score
+			point.Members = append(point.Members, MemberScore{PartyID: m.PartyID, Score: score, Weight: w})
+		}
+		if coveredWeight == 0 {
+			continue
+		}
+
+		point.Score = weighted / coveredWeight
+		point.Coverage = coveredWeight / dayWeight
+		for i := range point.Members {
+			point.Members[i].Weight /= coveredWeight
+		}
+		series.Points = append(series.Points, point)
+	}
+	return series, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+
+	"election-pulse-backend/models"
+)
+
+func TestMemberWeights(t *testing.T) {
+	tests := []struct {
+		name        string
+		memberships []models.AllianceMembership
+		weighting   string
+		want        []float64
+		wantOK      bool
+	}{
+		{
+			name:        "seats",
+			memberships: []models.AllianceMembership{{Seats: 170}, {Seats: 25}},
+			weighting:   WeightSeats,
+			want:        []float64{170, 25},
+			wantOK:      true,
+		},
+		{
+			name:        "missing seats get the mean",
+			memberships: []models.AllianceMembership{{Seats: 170}, {Seats: 30}, {}},
+			weighting:   WeightSeats,
+			want:        []float64{170, 30, 100},
+			wantOK:      true,
+		},
+		{
+			name:        "missing vote share gets the mean",
+			memberships: []models.AllianceMembership{{VoteShare: 37.7}, {}},
+			weighting:   WeightVotes,
+			want:        []float64{37.7, 37.7},
+			wantOK:      true,
+		},
+		{
+			name:        "no weights falls back to equal",
+			memberships: []models.AllianceMembership{{}, {}},
+			weighting:   WeightVotes,
+			want:        []float64{1, 1},
+			wantOK:      false,
+		},
+		{
+			name:        "equal ignores seats",
+			memberships: []models.AllianceMembership{{Seats: 170}, {}},
+			weighting:   WeightEqual,
+			want:        []float64{1, 1},
+			wantOK:      true,
+		},
+	}
+	for _, tt := range tests {
+		got, ok := memberWeights(tt.memberships, tt.weighting)
+		if ok != tt.wantOK {
+			t.Errorf("%s: ok = %v, want %v", tt.name, ok, tt.wantOK)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"election-pulse-backend/models"
+)
+
+// ScoreFromSentiment maps the model's -1.0..1.0 sentiment onto the 0-100
+// pulse score, as in the plan: WinningProbability = 50 + (RawScore * 50).
+func ScoreFromSentiment(raw float64) float64 {
+	// Clamp between -1 and 1 just in case
+	if raw > 1 {
+		raw = 1
+	}
+	if raw < -1 {
+		raw = -1
+	}
+	return 50 + (raw * 50)
+}
+
+// RunAnalysis builds the corpus for data and sends it to the AI, along with
+// the leaders the corpus mentions often enough to rate.
+func RunAnalysis(ctx context.Context, data *AggregatedData) (*AIAnalysisResult, error) {
+	corpus := BuildCorpus(data)
+
+	fmt.Printf("Corpus prepared: %d news, %d comments, %d reddit posts\n", len(data.News), len(data.Comments), len(data.RedditPosts))
+
+	mentions := FindLeaderMentions(data, ActiveLeaders())
+	names := make([]string, len(mentions))
+	for i, m := range mentions {
+		names[i] = m.Name
+	}
+
+	analysis, err := AnalyzeSentiment(ctx, corpus, names)
+	if err != nil {
+		return nil, err
+	}
+	analysis.LeaderScores = matchLeaderScores(analysis.LeaderScores, mentions)
+	return analysis, nil
+}
+
+// SourceBreakdown is what a snapshot stores in its source_breakdown column.
+type SourceBreakdown struct {
+	Sources  []SourceResult `json:"sources"`
+	Coverage Coverage       `json:"coverage"`
+}
+
+// NewSnapshot turns an analysis into a snapshot row stamped at the given time.
+// The analysis score is expected in the model's raw -1..1 range.
+func NewSnapshot(partyID uint, analysis *AIAnalysisResult, data *AggregatedData, at time.Time) models.SentimentSnapshot {
+	// Marshal KeyTopics to JSON string
+	keyTopicsJSON, _ := json.Marshal(analysis.KeyTopics)
+	breakdownJSON, _ := json.Marshal(SourceBreakdown{Sources: data.Sources, Coverage: data.Coverage})
+
+	return models.SentimentSnapshot{
+		PartyID:         partyID,
+		Score:           ScoreFromSentiment(analysis.SentimentScore),
+		KeyTopics:       string(keyTopicsJSON),
+		Emotion:         analysis.Emotion,
+		FactCheckNotes:  analysis.FactCheckNotes,
+		SourceBreakdown: string(breakdownJSON),
+		InorganicShare:  data.Inauthentic.InorganicShare,
+		Degraded:        data.Coverage.Degraded,
+		Volume:          data.Coverage.NewsItems + data.Coverage.SocialItems,
+		CreatedAt:       at.UTC(),
+	}
+}
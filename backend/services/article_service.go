@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// enrichConfig bounds how much work EnrichArticles does per run.
+type enrichConfig struct {
+	MaxBodyBytes int           // Body text kept per article
+	MaxFetches   int           // Article pages downloaded per run
+	Budget       time.Duration // Wall clock for the whole stage
+	PageTimeout  time.Duration
+}
+
+func loadEnrichConfig() enrichConfig {
+	return enrichConfig{
+		MaxBodyBytes: envInt("ARTICLE_MAX_BYTES", 2000),
+		MaxFetches:   envInt("ARTICLE_MAX_FETCHES", 10),
+		Budget:       envDuration("ARTICLE_ENRICH_BUDGET", 15*time.Second),
+		PageTimeout:  envDuration("ARTICLE_PAGE_TIMEOUT", 5*time.Second),
+	}
+}
+
+// minBodyRunes is the shortest summary we accept as a body. Many feeds repeat
+// the headline as the description, which tells the model nothing new.
+const minBodyRunes = 200
+
+// maxPageBytes caps a single HTML download.
+const maxPageBytes = 2 << 20
+
+// EnrichArticles fills Body for each news item, preferring text we already
+// have (feed content, then the feed/API description) and otherwise fetching
+// the article page and extracting its main text. Items left without a body
+// when the budget runs out fall back to whatever summary they carry.
+func EnrichArticles(ctx context.Context, items []NewsItem) {
+	cfg := loadEnrichConfig()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Budget)
+	defer cancel()
+
+	var toFetch []int
+	cached := make([]bool, len(items))
+	for i := range items {
+		it := &items[i]
+		if it.Body != "" {
+			cached[i] = true // Extracted on an earlier run
+			continue
+		}
+		if text := htmlToText(it.Content); utf8.RuneCountInString(text) >= minBodyRunes {
+			it.Body = truncateUTF8(text, cfg.MaxBodyBytes)
+		} else if text := htmlToText(it.Description); utf8.RuneCountInString(text) >= minBodyRunes {
+			it.Body = truncateUTF8(text, cfg.MaxBodyBytes)
+		} else if it.Link != "" && len(toFetch) < cfg.MaxFetches {
+			toFetch = append(toFetch, i)
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 4)
+	for _, i := range toFetch {
+		wg.Add(1)
+		go func(it *NewsItem) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			body, err := fetchArticleBody(ctx, it.Link, cfg)
+			if err != nil {
+				fmt.Printf("Article extraction failed for %s: %v\n", it.Link, err)
+				return
+			}
+			it.Body = body
+		}(&items[i])
+	}
+	wg.Wait()
+
+	enriched := 0
+	for i := range items {
+		it := &items[i]
+		if it.Body == "" {
+			// Short summaries are still better than nothing
+			it.Body = truncateUTF8(htmlToText(it.Description), cfg.MaxBodyBytes)
+			continue
+		}
+		enriched++
+		if !cached[i] && it.FeedItemID != 0 && db.DB != nil {
+			db.DB.Model(&models.FeedItem{ID: it.FeedItemID}).Update("body", it.Body)
+		}
+	}
+	fmt.Printf("Enriched %d/%d news items with article text (%d page fetches)\n", enriched, len(items), len(toFetch))
+}
+
+// fetchArticleBody downloads a page and extracts its readable main text.
+func fetchArticleBody(ctx context.Context, link string, cfg enrichConfig) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.PageTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", rssUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := newHTTPClient(0).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("http error: %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return "", fmt.Errorf("not an html page: %s", ct)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return "", err
+	}
+
+	body := extractMainText(doc)
+	if body == "" {
+		return "", fmt.Errorf("no article text found")
+	}
+	return truncateUTF8(body, cfg.MaxBodyBytes), nil
+}
+
+// extractMainText is a small readability heuristic: use an explicit article
+// container when the page has one, otherwise the parent element whose
+// paragraphs hold the most text.
+func extractMainText(doc *goquery.Document) string {
+	doc.Find("script, style, noscript, nav, header, footer, aside, form, figure, iframe").Remove()
+
+	for _, sel := range []string{`[itemprop="articleBody"]`, "article", "main"} {
+		if node := doc.Find(sel).First(); node.Length() > 0 {
+			if text := paragraphText(node); utf8.RuneCountInString(text) >= minBodyRunes {
+				return text
+			}
+		}
+	}
+
+	var best *goquery.Selection
+	bestLen := 0
+	doc.Find("p").Each(func(_ int, p *goquery.Selection) {
+		parent := p.Parent()
+		if n := len(paragraphText(parent)); n > bestLen {
+			best, bestLen = parent, n
+		}
+	})
+	if best == nil {
+		return ""
+	}
+	return paragraphText(best)
+}
+
+// paragraphText joins the non-trivial paragraphs under node.
+func paragraphText(node *goquery.Selection) string {
+	var parts []string
+	node.Find("p").Each(func(_ int, p *goquery.Selection) {
+		text := strings.Join(strings.Fields(p.Text()), " ")
+		// Skip bylines, captions and share prompts
+		if utf8.RuneCountInString(text) >= 40 {
+			parts = append(parts, text)
+		}
+	})
+	return strings.Join(parts, "\n")
+}
+
+// htmlToText strips markup from feed descriptions and content.
+func htmlToText(s string) string {
+	if s == "" {
+		return ""
+	}
+	if !strings.Contains(s, "<") {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(s))
+	if err != nil {
+		return ""
+	}
+	doc.Find("script, style").Remove()
+	return strings.Join(strings.Fields(doc.Text()), " ")
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune, which
+// matters for Tamil text.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Backfill bucket outcomes. Done and empty buckets are skipped on reruns;
+// failed ones are retried, and so are partial ones, which have a snapshot
+// but were missing a source (e.g. NewsData credits ran out).
+const (
+	BucketDone    = "done"
+	BucketPartial = "partial"
+	BucketEmpty   = "empty"
+	BucketFailed  = "failed"
+)
+
+type BackfillOptions struct {
+	From, To time.Time
+	Bucket   time.Duration // Width of each snapshot's window, usually a day
+	Force    bool          // Redo buckets that already finished
+}
+
+type bucketRange struct {
+	Start, End time.Time
+}
+
+// Backfill rebuilds the sentiment history of a party from archived sources:
+// NewsData.io's archive, Reddit search paged back by date and the feed items
+// we have stored. Each bucket is analysed on its own and written as a
+// snapshot stamped at the end of the bucket. Progress is recorded per bucket
+// so the command can be interrupted and rerun safely.
+func Backfill(ctx context.Context, party models.Party, opts BackfillOptions) error {
+	if opts.Bucket <= 0 {
+		opts.Bucket = 24 * time.Hour
+	}
+	if !opts.From.Before(opts.To) {
+		return fmt.Errorf("backfill range is empty: %s to %s", opts.From, opts.To)
+	}
+
+	pending, err := pendingBuckets(party.ID, opts)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Printf("Backfill for %s: nothing to do\n", party.Name)
+		return nil
+	}
+	fmt.Printf("Backfill for %s: %d buckets to process\n", party.Name, len(pending))
+
+	// Reddit listings are newest first, so page back once over the whole
+	// span and split the posts up, instead of re-reading them per bucket
+	// Archive queries page further back than live runs, so they get longer
+	timeout := envDuration("BACKFILL_SOURCE_TIMEOUT", 5*time.Minute)
+	var posts []RedditPost
+	reddit := runSource(ctx, SourceReddit, timeout, func(ctx context.Context) (int, error) {
+		var err error
+		posts, err = FetchRedditRange(ctx, party.Name, pending[0].Start, pending[len(pending)-1].End)
+		return len(posts), err
+	})
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	outcomes := make(map[string]int)
+	for _, b := range pending {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		status, err := backfillBucket(ctx, party, b, posts, reddit)
+		if err != nil {
+			fmt.Printf("Bucket %s failed: %v\n", b.Start.Format(time.RFC3339), err)
+			recordBucket(db.DB, party.ID, b, BucketFailed, nil, 0, err.Error())
+			status = BucketFailed
+		}
+		outcomes[status]++
+	}
+	fmt.Printf("Backfill for %s: %d done, %d partial, %d empty, %d failed\n", party.Name,
+		outcomes[BucketDone], outcomes[BucketPartial], outcomes[BucketEmpty], outcomes[BucketFailed])
+
+	// Servers would notice the new snapshots on their next leaderboard read,
+	// but refresh now so the history is there straight away
+	if err := RefreshHourlyScores(ctx); err != nil {
+		fmt.Printf("Backfill for %s: %v\n", party.Name, err)
+	}
+	if outcomes[BucketFailed] == len(pending) {
+		return fmt.Errorf("all %d buckets failed", len(pending))
+	}
+	return nil
+}
+
+// pendingBuckets splits the range into buckets and drops the ones already
+// finished, unless opts.Force is set.
+func pendingBuckets(partyID uint, opts BackfillOptions) ([]bucketRange, error) {
+	finished := make(map[int64]bool)
+	if !opts.Force {
+		var rows []models.BackfillBucket
+		err := db.DB.Where("party_id = ? AND bucket_start >= ? AND bucket_start < ? AND status IN ?",
+			partyID, opts.From.UTC(), opts.To.UTC(), []string{BucketDone, BucketEmpty}).Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			finished[r.BucketStart.Unix()] = true
+		}
+	}
+
+	var buckets []bucketRange
+	for start := opts.From.UTC(); start.Before(opts.To); start = start.Add(opts.Bucket) {
+		end := start.Add(opts.Bucket)
+		if end.After(opts.To) {
+			end = opts.To.UTC()
+		}
+		if !finished[start.Unix()] {
+			buckets = append(buckets, bucketRange{start, end})
+		}
+	}
+	return buckets, nil
+}
+
+// backfillBucket analyses one bucket and records it, returning the status it
+// was recorded with. An error means nothing was recorded yet.
+func backfillBucket(ctx context.Context, party models.Party, b bucketRange, posts []RedditPost, reddit SourceResult) (string, error) {
+	data := AggregatedData{}
+
+	timeout := envDuration("BACKFILL_SOURCE_TIMEOUT", 5*time.Minute)
+	archive := runSource(ctx, SourceNewsData, timeout, func(ctx context.Context) (int, error) {
+		news, err := FetchNewsDataWithOptions(ctx, party.Name, NewsDataOptions{
+			Mode:     NewsDataArchive,
+			From:     b.Start,
+			To:       b.End,
+			MaxPages: envInt("NEWSDATA_BACKFILL_PAGES", 1),
+		})
+		// The archive works in whole days, trim it to the bucket
+		for _, it := range news {
+			if !it.DateUnknown && !it.PublishedAt.Before(b.Start) && it.PublishedAt.Before(b.End) {
+				data.News = append(data.News, it)
+			}
+		}
+		return len(news), err
+	})
+
+	var storedErr error
+	stored := runSource(ctx, SourceRSS, timeout, func(ctx context.Context) (int, error) {
+		items, err := StoredFeedNews(party.Name, b.Start, b.End)
+		storedErr = err
+		data.News = append(data.News, items...)
+		return len(items), err
+	})
+	if storedErr != nil {
+		return "", fmt.Errorf("loading stored feed items: %w", storedErr)
+	}
+
+	for _, p := range posts {
+		if !p.CreatedAt.Before(b.Start) && p.CreatedAt.Before(b.End) {
+			data.RedditPosts = append(data.RedditPosts, p)
+		}
+	}
+	reddit.Items = redditItemCount(data.RedditPosts)
+	data.Sources = []SourceResult{stored, archive, reddit}
+
+	// A bucket missing a source isn't finished, so a rerun can fill it in.
+	// Skipped sources (no API key) are a setup choice and don't count.
+	var missing []string
+	for _, s := range []SourceResult{archive, reddit} {
+		if s.Status == SourceFailed {
+			missing = append(missing, s.Source+": "+s.Error)
+		}
+	}
+
+	items := len(data.News) + len(data.RedditPosts)
+	fmt.Printf("Bucket %s: %d news, %d reddit posts\n", b.Start.Format(time.RFC3339), len(data.News), len(data.RedditPosts))
+	if items == 0 {
+		if len(missing) > 0 {
+			return "", fmt.Errorf("no items, and sources failed: %s", strings.Join(missing, "; "))
+		}
+		return BucketEmpty, recordBucket(db.DB, party.ID, b, BucketEmpty, nil, 0, "")
+	}
+
+	EnrichArticles(ctx, data.News)
+	data.Inauthentic = DetectInauthentic(&data)
+	data.Coverage = EvaluateCoverage(&data)
+
+	analysis, err := RunAnalysis(ctx, &data)
+	if err != nil {
+		return "", fmt.Errorf("analysis failed: %w", err)
+	}
+
+	// Stamp inside the bucket so it lands on the right day in /trends
+	snapshot := NewSnapshot(party.ID, analysis, &data, b.End.Add(-time.Second))
+	snapshot.Backfilled = true
+
+	status, errMsg := BucketDone, ""
+	if len(missing) > 0 {
+		status, errMsg = BucketPartial, strings.Join(missing, "; ")
+	}
+	return status, db.DB.Transaction(func(tx *gorm.DB) error {
+		// A forced rerun replaces the bucket's earlier snapshot
+		var prev models.BackfillBucket
+		if err := tx.Where("party_id = ? AND bucket_start = ?", party.ID, b.Start).First(&prev).Error; err == nil && prev.SnapshotID != nil {
+			if err := tx.Where("snapshot_id = ?", *prev.SnapshotID).Delete(&models.LeaderSnapshot{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.SentimentSnapshot{}, *prev.SnapshotID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&snapshot).Error; err != nil {
+			return err
+		}
+		if leaders := NewLeaderSnapshots(analysis, snapshot); len(leaders) > 0 {
+			if err := tx.Create(&leaders).Error; err != nil {
+				return err
+			}
+		}
+		return recordBucket(tx, party.ID, b, status, &snapshot.ID, items, errMsg)
+	})
+}
+
+// recordBucket upserts the bucket's status row.
+func recordBucket(tx *gorm.DB, partyID uint, b bucketRange, status string, snapshotID *uint, items int, errMsg string) error {
+	row := models.BackfillBucket{PartyID: partyID, BucketStart: b.Start}
+	if err := tx.Where(row).FirstOrInit(&row).Error; err != nil {
+		return err
+	}
+	row.BucketEnd = b.End
+	row.Status = status
+	row.Items = items
+	row.Error = errMsg
+	if snapshotID != nil {
+		row.SnapshotID = snapshotID
+	}
+	return tx.Save(&row).Error
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BacktestMetrics are the headline errors of a backtest, overall or for one
+// region. The naive figures carry the base election's winners forward
+// unchanged, which is what the pulse has to beat to be worth anything.
+type BacktestMetrics struct {
+	Seats          int     `json:"seats"`
+	WinnerAccuracy float64 `json:"winner_accuracy"` // Share of seats whose projected winner won
+	NaiveAccuracy  float64 `json:"naive_accuracy"`  // Share of seats the previous winner's party held
+	Brier          float64 `json:"brier"`           // Mean squared error of the win probabilities per seat, 0 is perfect
+	SeatMAE        float64 `json:"seat_mae"`        // Mean absolute seat error per party
+	NaiveSeatMAE   float64 `json:"naive_seat_mae"`
+}
+
+// BacktestParty compares one party's projection with what happened.
+type BacktestParty struct {
+	PartyID         uint             `json:"party_id"` // 0 for Others
+	Name            string           `json:"name"`
+	Projected       int              `json:"projected_seats"` // Point projection
+	Expected        float64          `json:"expected_seats"`  // Sum of win probabilities
+	Low             int              `json:"low"`
+	High            int              `json:"high"`
+	Actual          int              `json:"actual_seats"`
+	Previous        int              `json:"previous_seats"` // At the base election
+	SeatError       int              `json:"seat_error"`     // Projected minus actual
+	InRange         bool             `json:"in_range"`       // Actual within low..high
+	ProjectedShare  *float64         `json:"projected_share,omitempty"`
+	ActualShare     *float64         `json:"actual_share,omitempty"`
+	ShareError      *float64         `json:"share_error,omitempty"`
+	Calibrated      *CalibratedShare `json:"calibrated,omitempty"` // Poll-calibrated share from the cutoff's sentiment
+	CalibratedError *float64         `json:"calibrated_error,omitempty"`
+}
+
+// RegionSeats is a party's projected and actual seats in one region.
+type RegionSeats struct {
+	Name      string `json:"name"`
+	Projected int    `json:"projected_seats"`
+	Actual    int    `json:"actual_seats"`
+}
+
+type BacktestRegion struct {
+	Region string `json:"region"`
+	BacktestMetrics
+	Parties []RegionSeats `json:"parties"`
+}
+
+type BacktestReport struct {
+	Year        int              `json:"year"`
+	BaseYear    int              `json:"base_year"`
+	Cutoff      time.Time        `json:"cutoff"`
+	Method      string           `json:"method"`
+	Runs        int              `json:"runs"`
+	Seed        uint64           `json:"seed"`
+	SwingPerPt  float64          `json:"swing_per_point"`
+	SwingSource string           `json:"swing_source"`
+	Calibration *Calibration     `json:"calibration"` // Fitted on polls before the cutoff, nil if there weren't enough
+	Unmatched   int              `json:"unmatched_seats"`
+	Overall     BacktestMetrics  `json:"overall"`
+	Parties     []BacktestParty  `json:"parties"`
+	Regions     []BacktestRegion `json:"regions"`
+	Warnings    []string         `json:"warnings"`
+}
+
+// Backtest projects a past election from what was known at opts.AsOf and
+// scores the projection against its actual results. opts.BaseYear is the
+// election before, which must be imported; the actual results usually come
+// straight from a file (ParseResults) so the tested election never has to be
+// in the database. Seats are matched by constituency number.
+func Backtest(opts ProjectionOptions, actual *ParsedResults) (*BacktestReport, error) {
+	if opts.AsOf.IsZero() {
+		return nil, errors.New("backtest needs a cutoff")
+	}
+	if opts.BaseYear == 0 || opts.BaseYear >= actual.Year {
+		return nil, fmt.Errorf("base year must be an election before %d", actual.Year)
+	}
+	opts.Seats = true
+	proj, err := Project(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &BacktestReport{
+		Year:        actual.Year,
+		BaseYear:    proj.BaseYear,
+		Cutoff:      opts.AsOf.UTC(),
+		Method:      proj.Method,
+		Runs:        proj.Runs,
+		Seed:        proj.Seed,
+		SwingPerPt:  proj.SwingPerPt,
+		SwingSource: proj.SwingSource,
+		Warnings:    []string{},
+	}
+	if cal, err := FitCalibrationAsOf(opts.AsOf); err == nil {
+		fit := *cal
+		fit.Points = nil
+		rep.Calibration = &fit
+	} else if errors.Is(err, ErrNotCalibrated) {
+		rep.Warnings = append(rep.Warnings, "not enough polls before the cutoff to calibrate")
+	} else {
+		return nil, err
+	}
+
+	// Results name parties as the source data does; fold them into the
+	// projection's contenders so both sides count the same way
+	names := make(map[string]string)
+	contenderOf := func(partyName string) string {
+		key := strings.ToUpper(partyName)
+		if n, ok := names[key]; ok {
+			return n
+		}
+		n := "Others"
+		if p, err := ResolveParty(partyName); err == nil && p.Active {
+			n = p.Name
+		}
+		names[key] = n
+		return n
+	}
+
+	outlooks := make(map[int]SeatOutlook, len(proj.Seats))
+	for _, o := range proj.Seats {
+		outlooks[o.Number] = o
+	}
+
+	overall := newBacktestTally()
+	regions := make(map[string]*backtestTally)
+	votes := make(map[string]float64)
+	totalVotes := 0.0
+	matched := 0
+	for _, s := range actual.Seats {
+		o, ok := outlooks[s.Seat.Number]
+		if !ok {
+			rep.Unmatched++
+			continue
+		}
+		matched++
+		winner := ""
+		for _, r := range s.Results {
+			name := contenderOf(r.PartyName)
+			votes[name] += float64(r.Votes)
+			totalVotes += float64(r.Votes)
+			if r.Won {
+				winner = name
+			}
+		}
+		prev := contenderOf(o.Previous)
+
+		region := o.Region
+		if region == "" {
+			region = s.Seat.Region
+		}
+		if region == "" {
+			region = "Unassigned"
+		}
+		if regions[region] == nil {
+			regions[region] = newBacktestTally()
+		}
+		overall.add(o, winner, prev)
+		regions[region].add(o, winner, prev)
+	}
+	rep.Unmatched += len(outlooks) - matched
+	if rep.Unmatched > 0 {
+		rep.Warnings = append(rep.Warnings, fmt.Sprintf("%d seats are in only one of the two elections and were left out", rep.Unmatched))
+	}
+	if matched == 0 {
+		return nil, errors.New("no constituency numbers in common with the base election")
+	}
+
+	swings := make(map[uint]PartySwing)
+	scored := false
+	for _, s := range proj.Swings {
+		swings[s.PartyID] = s
+		if s.Recent != nil && s.Baseline != nil {
+			scored = true
+		}
+	}
+	if !scored {
+		rep.Warnings = append(rep.Warnings, "no party has snapshots in both sentiment windows before the cutoff, so this is the base election carried forward")
+	}
+	recentFrom := opts.AsOf.AddDate(0, 0, -opts.RecentDays)
+
+	var partyNames []string
+	for _, sp := range proj.Parties {
+		bp := BacktestParty{
+			PartyID:   sp.ID,
+			Name:      sp.Name,
+			Projected: overall.projected[sp.Name],
+			Expected:  overall.expected[sp.Name],
+			Low:       sp.Low,
+			High:      sp.High,
+			Actual:    overall.actual[sp.Name],
+			Previous:  overall.previous[sp.Name],
+		}
+		bp.SeatError = bp.Projected - bp.Actual
+		bp.InRange = bp.Actual >= bp.Low && bp.Actual <= bp.High
+		if sw, ok := swings[sp.ID]; ok && totalVotes > 0 {
+			projected := sw.BaseShare + sw.Swing
+			actualShare := 100 * votes[sp.Name] / totalVotes
+			shareErr := projected - actualShare
+			bp.ProjectedShare, bp.ActualShare, bp.ShareError = &projected, &actualShare, &shareErr
+			if rep.Calibration != nil {
+				if score := meanScore(sp.ID, recentFrom, opts.AsOf); score != nil {
+					est := rep.Calibration.Estimate(*score)
+					calErr := est.Share - actualShare
+					bp.Calibrated, bp.CalibratedError = &est, &calErr
+				}
+			}
+		}
+		rep.Parties = append(rep.Parties, bp)
+		partyNames = append(partyNames, sp.Name)
+	}
+	sort.SliceStable(rep.Parties, func(a, b int) bool { return rep.Parties[a].Actual > rep.Parties[b].Actual })
+	rep.Overall = overall.metrics(partyNames)
+
+	for name, t := range regions {
+		r := BacktestRegion{Region: name, BacktestMetrics: t.metrics(partyNames), Parties: []RegionSeats{}}
+		for _, p := range partyNames {
+			if t.projected[p] > 0 || t.actual[p] > 0 {
+				r.Parties = append(r.Parties, RegionSeats{Name: p, Projected: t.projected[p], Actual: t.actual[p]})
+			}
+		}
+		sort.SliceStable(r.Parties, func(a, b int) bool { return r.Parties[a].Actual > r.Parties[b].Actual })
+		rep.Regions = append(rep.Regions, r)
+	}
+	sort.Slice(rep.Regions, func(a, b int) bool { return rep.Regions[a].Region < rep.Regions[b].Region })
+	return rep, nil
+}
+
+// backtestTally counts seats for the whole state or one region.
+type backtestTally struct {
+	projected, actual, previous map[string]int
+	expected                    map[string]float64
+	seats, hits, naiveHits      int
+	brier                       float64
+}
+
+func newBacktestTally() *backtestTally {
+	return &backtestTally{
+		projected: map[string]int{}, actual: map[string]int{}, previous: map[string]int{},
+		expected: map[string]float64{},
+	}
+}
+
+// add scores one seat: o is its projection, winner who actually won and prev
+// who held it at the base election.
+func (t *backtestTally) add(o SeatOutlook, winner, prev string) {
+	t.seats++
+	t.projected[o.Point]++
+	t.actual[winner]++
+	t.previous[prev]++
+	if o.Point == winner {
+		t.hits++
+	}
+	if prev == winner {
+		t.naiveHits++
+	}
+	sq := 0.0
+	for name, p := range o.WinProbs {
+		t.expected[name] += p
+		if name != winner {
+			sq += p * p
+		}
+	}
+	sq += (1 - o.WinProbs[winner]) * (1 - o.WinProbs[winner])
+	t.brier += sq
+}
+
+// metrics averages the tally; seat errors are over parties.
+func (t *backtestTally) metrics(parties []string) BacktestMetrics {
+	m := BacktestMetrics{
+		Seats:          t.seats,
+		WinnerAccuracy: float64(t.hits) / float64(t.seats),
+		NaiveAccuracy:  float64(t.naiveHits) / float64(t.seats),
+		Brier:          t.brier / float64(t.seats),
+	}
+	for _, name := range parties {
+		m.SeatMAE += math.Abs(float64(t.projected[name] - t.actual[name]))
+		m.NaiveSeatMAE += math.Abs(float64(t.previous[name] - t.actual[name]))
+	}
+	if len(parties) > 0 {
+		m.SeatMAE /= float64(len(parties))
+		m.NaiveSeatMAE /= float64(len(parties))
+	}
+	return m
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestBacktestMetrics(t *testing.T) {
+	type seat struct {
+		point, winner, prev string
+		probs               map[string]float64
+	}
+	tests := []struct {
+		name     string
+		seats    []seat
+		parties  []string
+		want     BacktestMetrics
+		expected map[string]float64
+	}{
+		{
+			name: "perfect",
+			seats: []seat{
+				{"DMK", "DMK", "DMK", map[string]float64{"DMK": 1}},
+				{"AIADMK", "AIADMK", "AIADMK", map[string]float64{"AIADMK": 1}},
+			},
+			parties:  []string{"DMK", "AIADMK"},
+			want:     BacktestMetrics{Seats: 2, WinnerAccuracy: 1, NaiveAccuracy: 1},
+			expected: map[string]float64{"DMK": 1, "AIADMK": 1},
+		},
+		{
+			name: "mixed",
+			seats: []seat{
+				{"DMK", "DMK", "DMK", map[string]float64{"DMK": 1}},
+				{"DMK", "AIADMK", "AIADMK", map[string]float64{"DMK": 0.6, "AIADMK": 0.4}},
+				{"AIADMK", "AIADMK", "DMK", map[string]float64{"AIADMK": 0.5, "DMK": 0.5}},
+				// The winner never came up in a run
+				{"TVK", "Others", "Others", map[string]float64{"TVK": 0.8}},
+			},
+			parties: []string{"DMK", "AIADMK", "TVK"},
+			want: BacktestMetrics{
+				Seats:          4,
+				WinnerAccuracy: 0.5,
+				NaiveAccuracy:  0.75,
+				Brier:          (0 + 0.72 + 0.5 + 1.64) / 4,
+				SeatMAE:        1,
+				NaiveSeatMAE:   2.0 / 3,
+			},
+			expected: map[string]float64{"DMK": 2.1, "AIADMK": 0.9, "TVK": 0.8},
+		},
+		{
+			name:    "no parties",
+			seats:   []seat{{"DMK", "AIADMK", "DMK", map[string]float64{"DMK": 1}}},
+			parties: nil,
+			want:    BacktestMetrics{Seats: 1, Brier: 2},
+		},
+	}
+	for _, tt := range tests {
+		tally := newBacktestTally()
+		for _, s := range tt.seats {
+			tally.add(SeatOutlook{Point: s.point, WinProbs: s.probs}, s.winner, s.prev)
+		}
+		got := tally.metrics(tt.parties)
+		if got.Seats != tt.want.Seats || !near(got.WinnerAccuracy, tt.want.WinnerAccuracy) ||
+			!near(got.NaiveAccuracy, tt.want.NaiveAccuracy) || !near(got.Brier, tt.want.Brier) ||
+			!near(got.SeatMAE, tt.want.SeatMAE) || !near(got.NaiveSeatMAE, tt.want.NaiveSeatMAE) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		for name, want := range tt.expected {
+			if !near(tally.expected[name], want) {
+				t.Errorf("%s: expected seats for %s = %v, want %v", tt.name, name, tally.expected[name], want)
+			}
+		}
+	}
+}
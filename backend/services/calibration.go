@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+// ErrNotCalibrated is returned when there aren't enough polls with matching
+// sentiment data to fit the calibration.
+var ErrNotCalibrated = errors.New("not enough polls with sentiment data to calibrate")
+
+// CalibrationPoint pairs a party's share in one poll with its mean sentiment
+// score over the days before the poll.
+type CalibrationPoint struct {
+	PollID   uint      `json:"poll_id"`
+	Pollster string    `json:"pollster"`
+	Date     time.Time `json:"date"`
+	PartyID  uint      `json:"party_id"`
+	Score    float64   `json:"score"`
+	Share    float64   `json:"share"`
+	Residual float64   `json:"residual"` // Share minus fitted share
+}
+
+// Calibration is a least squares fit of share = Intercept + Slope*score,
+// pooled over all tracked parties and polls.
+type Calibration struct {
+	Intercept  float64            `json:"intercept"`
+	Slope      float64            `json:"slope"`    // Vote share points per sentiment point
+	SlopeSE    float64            `json:"slope_se"` // Standard error of Slope
+	R2         float64            `json:"r2"`
+	RMSE       float64            `json:"rmse"` // Residual standard error, share points
+	N          int                `json:"n"`
+	Polls      int                `json:"polls"`
+	WindowDays int                `json:"window_days"`
+	FittedAt   time.Time          `json:"fitted_at"`
+	Points     []CalibrationPoint `json:"points"`
+
+	meanX, sxx float64
+}
+
+// CalibratedShare is a sentiment score mapped to a vote share, with a 95%
+// prediction interval.
+type CalibratedShare struct {
+	Share float64 `json:"share"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+}
+
+// Estimate maps a 0-100 sentiment score to a vote share. The interval covers
+// both the fit's uncertainty and the scatter of polls around it, and widens
+// for scores far from the ones the fit has seen.
+func (c *Calibration) Estimate(score float64) CalibratedShare {
+	y := c.Intercept + c.Slope*score
+	half := 1.96 * c.RMSE * math.Sqrt(1+1/float64(c.N)+(score-c.meanX)*(score-c.meanX)/c.sxx)
+	return CalibratedShare{
+		Share: round1(clampShare(y)),
+		Low:   round1(clampShare(y - half)),
+		High:  round1(clampShare(y + half)),
+	}
+}
+
+func clampShare(v float64) float64 {
+	return math.Max(0, math.Min(100, v))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// The fit only changes when polls are imported or new snapshots land, so it
+// is cached for CALIBRATION_TTL and dropped on import.
+var (
+	calibrationMu  sync.Mutex
+	calibration    *Calibration
+	calibrationErr error
+	calibratedAt   time.Time
+)
+
+// InvalidateCalibration drops the cached fit.
+func InvalidateCalibration() {
+	calibrationMu.Lock()
+	calibration, calibrationErr = nil, nil
+	calibratedAt = time.Time{}
+	calibrationMu.Unlock()
+}
+
+// CurrentCalibration returns the cached fit, refitting it if it has expired.
+func CurrentCalibration() (*Calibration, error) {
+	calibrationMu.Lock()
+	defer calibrationMu.Unlock()
+	if !calibratedAt.IsZero() && time.Since(calibratedAt) < envDuration("CALIBRATION_TTL", time.Hour) {
+		return calibration, calibrationErr
+	}
+	calibration, calibrationErr = FitCalibrationAsOf(time.Time{})
+	calibratedAt = time.Now()
+	return calibration, calibrationErr
+}
+
+// FitCalibrationAsOf fits the calibration from scratch. Each tracked party's
+// share in each poll is paired with its mean score over the
+// CALIBRATION_WINDOW days up to and including the poll date; shares without
+// snapshots in that window are left out. A non-zero asOf uses only polls
+// dated before that day, as a backtest needs.
+func FitCalibrationAsOf(asOf time.Time) (*Calibration, error) {
+	window := envInt("CALIBRATION_WINDOW", 7)
+	q := db.DB.Preload("Shares").Order("date")
+	if !asOf.IsZero() {
+		y, m, d := asOf.UTC().Date()
+		q = q.Where("date < ?", time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+	}
+	var polls []models.Poll
+	if err := q.Find(&polls).Error; err != nil {
+		return nil, err
+	}
+
+	c := &Calibration{WindowDays: window, Points: []CalibrationPoint{}}
+	for _, p := range polls {
+		day := time.Date(p.Date.Year(), p.Date.Month(), p.Date.Day(), 0, 0, 0, 0, IST)
+		to := day.AddDate(0, 0, 1)
+		from := to.AddDate(0, 0, -window)
+		used := false
+		for _, s := range p.Shares {
+			if s.PartyID == nil {
+				continue
+			}
+			score := meanScore(*s.PartyID, from, to)
+			if score == nil {
+				continue
+			}
+			c.Points = append(c.Points, CalibrationPoint{
+				PollID: p.ID, Pollster: p.Pollster, Date: p.Date,
+				PartyID: *s.PartyID, Score: *score, Share: s.Share,
+			})
+			used = true
+		}
+		if used {
+			c.Polls++
+		}
+	}
+	if err := c.fit(); err != nil {
+		return nil, err
+	}
+	c.FittedAt = time.Now()
+	return c, nil
+}
+
+// fit runs ordinary least squares over c.Points.
+func (c *Calibration) fit() error {
+	n := len(c.Points)
+	if n < envInt("CALIBRATION_MIN_POINTS", 5) || n < 3 {
+		return ErrNotCalibrated
+	}
+	var sumX, sumY float64
+	for _, p := range c.Points {
+		sumX += p.Score
+		sumY += p.Share
+	}
+	meanX, meanY := sumX/float64(n), sumY/float64(n)
+	var sxx, sxy, syy float64
+	for _, p := range c.Points {
+		dx, dy := p.Score-meanX, p.Share-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return ErrNotCalibrated // Every point has the same score, so no slope
+	}
+
+	c.N = n
+	c.Slope = sxy / sxx
+	c.Intercept = meanY - c.Slope*meanX
+	c.meanX, c.sxx = meanX, sxx
+	var sse float64
+	for i := range c.Points {
+		p := &c.Points[i]
+		p.Residual = p.Share - (c.Intercept + c.Slope*p.Score)
+		sse += p.Residual * p.Residual
+	}
+	c.RMSE = math.Sqrt(sse / float64(n-2))
+	c.SlopeSE = c.RMSE / math.Sqrt(sxx)
+	if syy > 0 {
+		c.R2 = 1 - sse/syy
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func calibrationPoints(xy ...float64) []CalibrationPoint {
+	var points []CalibrationPoint
+	for i := 0; i+1 < len(xy); i += 2 {
+		points = append(points, CalibrationPoint{Score: xy[i], Share: xy[i+1]})
+	}
+	return points
+}
+
+func TestCalibrationFit(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []CalibrationPoint
+		minPoints string
+		wantErr   error
+		slope     float64
+		intercept float64
+		r2        float64
+		rmse      float64
+	}{
+		{
+			name:      "exact line",
+			points:    calibrationPoints(0, 10, 10, 15, 20, 20, 30, 25, 40, 30),
+			slope:     0.5,
+			intercept: 10,
+			r2:        1,
+		},
+		{
+			name:      "noisy",
+			points:    calibrationPoints(0, 10, 10, 16, 20, 18, 30, 26, 40, 30),
+			slope:     0.5,
+			intercept: 10,
+			r2:        1 - 6.0/256,
+			rmse:      math.Sqrt(2),
+		},
+		{
+			name:    "too few points",
+			points:  calibrationPoints(0, 10, 10, 15, 20, 20, 30, 25),
+			wantErr: ErrNotCalibrated,
+		},
+		{
+			name:      "lower minimum",
+			points:    calibrationPoints(0, 10, 10, 15, 20, 20),
+			minPoints: "3",
+			slope:     0.5,
+			intercept: 10,
+			r2:        1,
+		},
+		{
+			name:    "no spread in scores",
+			points:  calibrationPoints(50, 10, 50, 15, 50, 20, 50, 25, 50, 30),
+			wantErr: ErrNotCalibrated,
+		},
+	}
+	for _, tt := range tests {
+		t.Setenv("CALIBRATION_MIN_POINTS", tt.minPoints)
+		c := &Calibration{Points: tt.points}
+		err := c.fit()
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: err = %v, want %v", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if c.N != len(tt.points) || !near(c.Slope, tt.slope) || !near(c.Intercept, tt.intercept) ||
+			!near(c.R2, tt.r2) || !near(c.RMSE, tt.rmse) {
+			t.Errorf("%s: got n=%d slope=%v intercept=%v r2=%v rmse=%v", tt.name, c.N, c.Slope, c.Intercept, c.R2, c.RMSE)
+		}
+		for _, p := range c.Points {
+			if !near(p.Residual, p.Share-(c.Intercept+c.Slope*p.Score)) {
+				t.Errorf("%s: residual %v at score %v", tt.name, p.Residual, p.Score)
+			}
+		}
+	}
+}
+
+func TestCalibrationEstimate(t *testing.T) {
+	t.Setenv("CALIBRATION_MIN_POINTS", "")
+	exact := &Calibration{Points: calibrationPoints(0, 10, 10, 15, 20, 20, 30, 25, 40, 30)}
+	noisy := &Calibration{Points: calibrationPoints(0, 10, 10, 16, 20, 18, 30, 26, 40, 30)}
+	for _, c := range []*Calibration{exact, noisy} {
+		if err := c.fit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		c     *Calibration
+		score float64
+		want  CalibratedShare
+	}{
+		{"exact", exact, 20, CalibratedShare{Share: 20, Low: 20, High: 20}},
+		{"exact clamped", exact, 200, CalibratedShare{Share: 100, Low: 100, High: 100}},
+		{"noisy at the mean", noisy, 20, CalibratedShare{Share: 20, Low: 17, High: 23}},
+		{"noisy far out", noisy, 80, CalibratedShare{Share: 50, Low: 43.9, High: 56.1}},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Estimate(tt.score); got != tt.want {
+			t.Errorf("%s: Estimate(%v) = %+v, want %+v", tt.name, tt.score, got, tt.want)
+		}
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// AnalysisRun is the outcome of one analysis pipeline, possibly shared by
+// several callers.
+type AnalysisRun struct {
+	Analysis *AIAnalysisResult
+	Data     *AggregatedData
+	Snapshot *models.SentimentSnapshot // nil in topic mode
+	Joint    *JointResult              // Joint comparisons only
+	Shared   bool                      // Result came from a run another request started
+}
+
+// analysisFlights coalesces concurrent analyses within this process. Across
+// replicas the same key is guarded by a Postgres advisory lock.
+var analysisFlights singleflight.Group
+
+// AnalyzeParty fetches, analyses and stores a snapshot for a party. Concurrent
+// calls for the same party and news window share one pipeline run and one
+// snapshot, in this process through singleflight and across replicas through
+// an advisory lock.
+func AnalyzeParty(ctx context.Context, party models.Party, timeout time.Duration) (*AnalysisRun, error) {
+	key := fmt.Sprintf("analyze:party:%d:%s", party.ID, envDuration("NEWS_MAX_AGE", 72*time.Hour))
+	return coalesce(ctx, key, timeout, func(ctx context.Context) (*AnalysisRun, error) {
+		return analyzeLocked(ctx, key, party)
+	})
+}
+
+// AnalyzeTopic runs the pipeline for an ad-hoc topic such as "NEET" or
+// "Chennai floods". Nothing is stored, so only in-process callers share runs.
+func AnalyzeTopic(ctx context.Context, topic string, timeout time.Duration) (*AnalysisRun, error) {
+	key := fmt.Sprintf("analyze:topic:%s:%s", strings.ToLower(strings.TrimSpace(topic)), envDuration("NEWS_MAX_AGE", 72*time.Hour))
+	return coalesce(ctx, key, timeout, func(ctx context.Context) (*AnalysisRun, error) {
+		return runPipeline(ctx, models.Party{}, topic)
+	})
+}
+
+// coalesce runs fn once per key for all concurrent callers. The run is
+// detached from any single caller, so one caller giving up doesn't fail the
+// others; each caller still stops waiting when its own ctx ends.
+func coalesce(ctx context.Context, key string, timeout time.Duration, fn func(ctx context.Context) (*AnalysisRun, error)) (*AnalysisRun, error) {
+	ch := analysisFlights.DoChan(key, func() (interface{}, error) {
+		runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		return fn(runCtx)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		run := *res.Val.(*AnalysisRun) // Copy so Shared is per caller
+		run.Shared = run.Shared || res.Shared
+		return &run, nil
+	}
+}
+
+// analyzeLocked runs the pipeline under the cross-replica lock. If another
+// replica wrote a snapshot for the party while we were waiting for the lock,
+// that snapshot is returned instead of running again.
+func analyzeLocked(ctx context.Context, key string, party models.Party) (*AnalysisRun, error) {
+	started := time.Now()
+	var run *AnalysisRun
+	err := withAdvisoryLock(ctx, key, func() error {
+		var snapshot models.SentimentSnapshot
+		err := db.DB.Where("party_id = ? AND created_at >= ? AND backfilled = ?", party.ID, started.UTC(), false).
+			Order("created_at desc").First(&snapshot).Error
+		if err == nil {
+			fmt.Printf("Reusing snapshot %d for %s written by a concurrent run\n", snapshot.ID, party.Name)
+			run = runFromSnapshot(snapshot)
+			return nil
+		}
+
+		run, err = runPipeline(ctx, party, party.Name)
+		return err
+	})
+	return run, err
+}
+
+// runPipeline is the fetch, analyse and store sequence behind /analyze. A
+// zero party (topic mode) is analysed for query but not stored.
+func runPipeline(ctx context.Context, party models.Party, query string) (*AnalysisRun, error) {
+	// 1. Fetch Data
+	data, err := FetchAllData(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data: %w", err)
+	}
+
+	// 2. Analyze with AI
+	analysis, err := RunAnalysis(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("AI analysis failed: %w", err)
+	}
+
+	run := &AnalysisRun{Analysis: analysis, Data: data}
+
+	// 3. Save Snapshot
+	if party.ID != 0 {
+		snapshot := NewSnapshot(party.ID, analysis, data, time.Now())
+		if err := db.DB.Create(&snapshot).Error; err != nil {
+			fmt.Printf("Failed to save snapshot for %s: %v\n", party.Name, err)
+		} else {
+			run.Snapshot = &snapshot
+			if leaders := NewLeaderSnapshots(analysis, snapshot); len(leaders) > 0 {
+				if err := db.DB.Create(&leaders).Error; err != nil {
+					fmt.Printf("Failed to save leader snapshots for %s: %v\n", party.Name, err)
+				}
+			}
+		}
+	}
+
+	// The API reports 0-100 in both modes; stored snapshots already are
+	analysis.SentimentScore = ScoreFromSentiment(analysis.SentimentScore)
+	for i := range analysis.LeaderScores {
+		analysis.LeaderScores[i].SentimentScore = ScoreFromSentiment(analysis.LeaderScores[i].SentimentScore)
+	}
+	return run, nil
+}
+
+// runFromSnapshot rebuilds a run from a stored snapshot. Only what the
+// snapshot keeps is available: the full inauthenticity report isn't stored.
+func runFromSnapshot(s models.SentimentSnapshot) *AnalysisRun {
+	var topics []string
+	json.Unmarshal([]byte(s.KeyTopics), &topics)
+	var breakdown SourceBreakdown
+	json.Unmarshal([]byte(s.SourceBreakdown), &breakdown)
+
+	var leaderRows []models.LeaderSnapshot
+	db.DB.Where("snapshot_id = ?", s.ID).Find(&leaderRows)
+	leaders := make([]LeaderScore, 0, len(leaderRows))
+	for _, l := range leaderRows {
+		var leader models.Leader
+		db.DB.Select("name").First(&leader, l.LeaderID)
+		leaders = append(leaders, LeaderScore{Name: leader.Name, SentimentScore: l.Score, Emotion: l.Emotion, LeaderID: l.LeaderID, Mentions: l.Mentions})
+	}
+
+	return &AnalysisRun{
+		Analysis: &AIAnalysisResult{
+			SentimentScore: s.Score,
+			Emotion:        s.Emotion,
+			KeyTopics:      topics,
+			FactCheckNotes: s.FactCheckNotes,
+			LeaderScores:   leaders,
+		},
+		Data: &AggregatedData{
+			Inauthentic: InauthenticReport{InorganicShare: s.InorganicShare},
+			Sources:     breakdown.Sources,
+			Coverage:    breakdown.Coverage,
+		},
+		Snapshot: &s,
+		Shared:   true,
+	}
+}
+
+// withAdvisoryLock runs fn while holding a session-level Postgres advisory
+// lock derived from key, waiting for other holders first. Without a database
+// fn just runs.
+func withAdvisoryLock(ctx context.Context, key string, fn func() error) error {
+	if db.DB == nil {
+		return fn()
+	}
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		return fn()
+	}
+
+	// Session locks belong to a connection, so pin one for lock and unlock
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("advisory lock: %w", err)
+	}
+	defer conn.Close()
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	lockID := int64(h.Sum64())
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("advisory lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			// Don't hand a connection that may still hold the lock back to the pool
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+// PartyLatest is a party's most recent snapshot with its movement.
+type PartyLatest struct {
+	PartyID    uint      `json:"party_id"`
+	Name       string    `json:"name"`
+	Score      float64   `json:"score"`
+	Emotion    string    `json:"emotion"`
+	Degraded   bool      `json:"degraded"`
+	CreatedAt  time.Time `json:"created_at"`
+	DeltaPrev  *float64  `json:"delta_prev"`   // Change since the snapshot before, nil if there is none
+	DeltaWeek  *float64  `json:"delta_7d"`     // Change against the latest snapshot at least 7 days older
+	GapToFirst *float64  `json:"gap_to_first"` // Distance behind the top party, 0 for the leader; nil without a snapshot
+	Rank       *int      `json:"rank"`         // Nil without a snapshot
+}
+
+// TopicMatrix counts how many snapshots of each party in the window named a
+// topic. Counts[i][j] is for Topics[i] and the j-th party of the comparison.
+type TopicMatrix struct {
+	Topics []string `json:"topics"`
+	Counts [][]int  `json:"counts"`
+}
+
+// PartyComparison lines several parties up on one date axis.
+type PartyComparison struct {
+	Parties []PartyLatest `json:"parties"` // In the order requested; Rank gives the standing
+	Dates   []string      `json:"dates"`   // YYYY-MM-DD, IST
+	Series  [][]*float64  `json:"series"`  // Series[j] is party j's daily mean score per date, nil on days without data
+	Topics  TopicMatrix   `json:"topic_matrix"`
+	Joint   *JointResult  `json:"joint,omitempty"`
+}
+
+// ErrQuotaExhausted is returned when a run would need more API quota than is
+// left for the day.
+var ErrQuotaExhausted = errors.New("not enough API quota left today")
+
+// Joint results aren't stored, so recent ones are kept in memory for
+// JOINT_CACHE_TTL to stop repeated requests from refetching everything. The
+// cache is per replica: the advisory lock only keeps two replicas from
+// running the same comparison at once, and the second still runs its own
+// afterwards.
+const jointCacheSize = 100
+
+var (
+	jointMu    sync.Mutex
+	jointCache = make(map[string]*JointResult)
+)
+
+// CompareJoint runs AnalyzeJoint through the same coalescing as /analyze:
+// concurrent requests for the same set of parties share one run, replicas
+// take turns through an advisory lock, and a result this replica produced
+// within JOINT_CACHE_TTL is reused. The quota for every party's fetch is checked up
+// front, since a joint run costs about one /analyze per party.
+func CompareJoint(ctx context.Context, parties []models.Party, timeout time.Duration) (*JointResult, error) {
+	ids := make([]string, len(parties))
+	for i, p := range parties {
+		ids[i] = fmt.Sprint(p.ID)
+	}
+	sort.Strings(ids)
+	key := fmt.Sprintf("analyze:joint:%s:%s", strings.Join(ids, ","), envDuration("NEWS_MAX_AGE", 72*time.Hour))
+
+	ttl := envDuration("JOINT_CACHE_TTL", time.Hour)
+
+	run, err := coalesce(ctx, key, timeout, func(ctx context.Context) (*AnalysisRun, error) {
+		var run *AnalysisRun
+		err := withAdvisoryLock(ctx, key, func() error {
+			jointMu.Lock()
+			cached := jointCache[key]
+			jointMu.Unlock()
+			if cached != nil && time.Since(cached.GeneratedAt) < ttl {
+				run = &AnalysisRun{Joint: cached, Shared: true}
+				return nil
+			}
+
+			if err := jointAffordable(len(parties)); err != nil {
+				return err
+			}
+			joint, err := AnalyzeJoint(ctx, parties)
+			if err != nil {
+				return err
+			}
+			jointMu.Lock()
+			for k, c := range jointCache {
+				if time.Since(c.GeneratedAt) >= ttl {
+					delete(jointCache, k)
+				}
+			}
+			if len(jointCache) < jointCacheSize {
+				jointCache[key] = joint
+			}
+			jointMu.Unlock()
+			run = &AnalysisRun{Joint: joint}
+			return nil
+		})
+		return run, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	joint := *run.Joint // Copy so Shared is per caller
+	joint.Shared = run.Shared
+	return &joint, nil
+}
+
+// jointAffordable checks that YouTube and NewsData have quota left for a
+// fetch per party.
+func jointAffordable(n int) error {
+	if units := n * EstimateYouTubeRunCost(); !YouTubeQuota.CanAfford(units) {
+		return fmt.Errorf("%w: %d parties need ~%d YouTube units, %d left", ErrQuotaExhausted, n, units, YouTubeQuota.Remaining())
+	}
+	if credits := n * max(envInt("NEWSDATA_MAX_PAGES", 2), 1); !NewsDataCredits.CanAfford(credits) {
+		return fmt.Errorf("%w: %d parties need ~%d NewsData credits, %d left", ErrQuotaExhausted, n, credits, NewsDataCredits.Remaining())
+	}
+	return nil
+}
+
+// CompareParties builds the stored-data side of a comparison: daily series,
+// latest scores and the shared-topic matrix.
+func CompareParties(parties []models.Party, from, to time.Time) (*PartyComparison, error) {
+	ids := make([]uint, len(parties))
+	index := make(map[uint]int, len(parties))
+	for i, p := range parties {
+		ids[i] = p.ID
+		index[p.ID] = i
+	}
+
+	var snapshots []models.SentimentSnapshot
+	err := db.DB.Select("party_id", "score", "key_topics", "created_at").
+		Where("party_id IN ? AND created_at >= ? AND created_at < ?", ids, from.UTC(), to.UTC()).
+		Order("created_at").Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &PartyComparison{Dates: []string{}, Series: make([][]*float64, len(parties))}
+
+	// Daily means, IST days
+	type acc struct {
+		sum float64
+		n   int
+	}
+	byDay := make(map[string][]acc)
+	topicCounts := make(map[string][]int)
+	topicLabel := make(map[string]string)
+	for _, s := range snapshots {
+		j := index[s.PartyID]
+		day := s.CreatedAt.In(IST).Format("2006-01-02")
+		if byDay[day] == nil {
+			byDay[day] = make([]acc, len(parties))
+		}
+		byDay[day][j].sum += s.Score
+		byDay[day][j].n++
+
+		var topics []string
+		json.Unmarshal([]byte(s.KeyTopics), &topics)
+		for _, t := range topics {
+			key := strings.ToLower(strings.TrimSpace(t))
+			if key == "" {
+				continue
+			}
+			if topicCounts[key] == nil {
+				topicCounts[key] = make([]int, len(parties))
+				topicLabel[key] = strings.TrimSpace(t)
+			}
+			topicCounts[key][j]++
+		}
+	}
+
+	for day := range byDay {
+		cmp.Dates = append(cmp.Dates, day)
+	}
+	sort.Strings(cmp.Dates)
+	for j := range parties {
+		cmp.Series[j] = make([]*float64, len(cmp.Dates))
+		for i, day := range cmp.Dates {
+			if a := byDay[day][j]; a.n > 0 {
+				mean := a.sum / float64(a.n)
+				cmp.Series[j][i] = &mean
+			}
+		}
+	}
+
+	cmp.Topics = topicMatrix(topicCounts, topicLabel)
+
+	for _, p := range parties {
+		cmp.Parties = append(cmp.Parties, latestFor(p))
+	}
+	rankByScore(cmp.Parties, func(p *PartyLatest) (float64, bool) { return p.Score, !p.CreatedAt.IsZero() }, func(p *PartyLatest, rank int, gap float64) {
+		p.Rank = &rank
+		p.GapToFirst = &gap
+	})
+	return cmp, nil
+}
+
+// topicMatrix keeps topics named for at least two parties, most widely shared
+// first. With nothing shared it falls back to every topic so the matrix isn't
+// empty on a quiet week.
+func topicMatrix(counts map[string][]int, labels map[string]string) TopicMatrix {
+	spread := func(c []int) (parties, total int) {
+		for _, n := range c {
+			if n > 0 {
+				parties++
+			}
+			total += n
+		}
+		return
+	}
+
+	var keys []string
+	for k, c := range counts {
+		if p, _ := spread(c); p >= 2 {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		for k := range counts {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		pa, ta := spread(counts[keys[a]])
+		pb, tb := spread(counts[keys[b]])
+		if pa != pb {
+			return pa > pb
+		}
+		if ta != tb {
+			return ta > tb
+		}
+		return keys[a] < keys[b]
+	})
+	if limit := envInt("COMPARE_MAX_TOPICS", 20); len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	m := TopicMatrix{Topics: []string{}, Counts: [][]int{}}
+	for _, k := range keys {
+		m.Topics = append(m.Topics, labels[k])
+		m.Counts = append(m.Counts, counts[k])
+	}
+	return m
+}
+
+// latestFor reads a party's newest snapshot and the deltas against the one
+// before it and against a week earlier.
+func latestFor(p models.Party) PartyLatest {
+	out := PartyLatest{PartyID: p.ID, Name: p.Name}
+	var recent []models.SentimentSnapshot
+	db.DB.Where("party_id = ?", p.ID).Order("created_at desc").Limit(2).Find(&recent)
+	if len(recent) == 0 {
+		return out
+	}
+	latest := recent[0]
+	out.Score = latest.Score
+	out.Emotion = latest.Emotion
+	out.Degraded = latest.Degraded
+	out.CreatedAt = latest.CreatedAt
+	if len(recent) > 1 {
+		d := latest.Score - recent[1].Score
+		out.DeltaPrev = &d
+	}
+	var weekAgo models.SentimentSnapshot
+	if db.DB.Where("party_id = ? AND created_at <= ?", p.ID, latest.CreatedAt.AddDate(0, 0, -7)).
+		Order("created_at desc").First(&weekAgo).Error == nil {
+		d := latest.Score - weekAgo.Score
+		out.DeltaWeek = &d
+	}
+	return out
+}
+
+// rankByScore assigns 1-based ranks by descending score, and each item's gap
+// to the top score. Items whose score isn't known (a party without a
+// snapshot) are left unranked rather than ranked as 0.
+func rankByScore[T any](items []T, score func(*T) (float64, bool), set func(*T, int, float64)) {
+	var order []int
+	scores := make([]float64, len(items))
+	for i := range items {
+		var ok bool
+		if scores[i], ok = score(&items[i]); ok {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	if len(order) == 0 {
+		return
+	}
+	top := scores[order[0]]
+	for rank, i := range order {
+		set(&items[i], rank+1, top-scores[i])
+	}
+}
+
+// JointStanding is one party's place in a joint analysis.
+type JointStanding struct {
+	Party          string  `json:"party"`
+	SentimentScore float64 `json:"sentiment_score"` // 0-100, comparable across the parties of this run only
+	Emotion        string  `json:"emotion"`
+	Rank           int     `json:"rank"`
+	Summary        string  `json:"summary"`
+}
+
+// JointResult is the outcome of scoring several parties in one LLM call.
+type JointResult struct {
+	Standings    []JointStanding `json:"standings"`
+	SharedTopics []string        `json:"shared_topics"`
+	Notes        string          `json:"notes"`
+	Sources      []SourceResult  `json:"sources"` // Per party and source, named "<party>/<source>"
+	GeneratedAt  time.Time       `json:"generated_at"`
+	Shared       bool            `json:"shared"` // Reused from a concurrent or recent run
+}
+
+// AnalyzeJoint fetches every party's data, then asks the model to score them
+// against each other from one combined corpus. Separate runs see different
+// corpora, so their scores drift; here all parties are judged on the same
+// evidence and scale. Nothing is stored.
+func AnalyzeJoint(ctx context.Context, parties []models.Party) (*JointResult, error) {
+	datas := make([]*AggregatedData, len(parties))
+	errs := make([]error, len(parties))
+	var wg sync.WaitGroup
+	for i, p := range parties {
+		wg.Add(1)
+		go func(i int, p models.Party) {
+			defer wg.Done()
+			datas[i], errs[i] = FetchAllData(ctx, p.Name)
+		}(i, p)
+	}
+	wg.Wait()
+
+	result := &JointResult{}
+	var b strings.Builder
+	names := make([]string, len(parties))
+	for i, p := range parties {
+		names[i] = p.Name
+		if errs[i] != nil {
+			return nil, fmt.Errorf("failed to fetch data for %s: %w", p.Name, errs[i])
+		}
+		for _, s := range datas[i].Sources {
+			s.Source = p.Name + "/" + s.Source
+			result.Sources = append(result.Sources, s)
+		}
+		b.WriteString("\n=== PARTY: " + p.Name + " ===\n")
+		b.WriteString(BuildCorpus(datas[i]))
+	}
+
+	prompt := fmt.Sprintf(`
+You are an expert political analyst specializing in Tamil Nadu politics.
+Below are separate sections of news and social media data, one per party: %s.
+
+Judge the parties AGAINST EACH OTHER on one common scale. A score should say how public sentiment toward a party compares with the others in this same data, so a 0.3 for one party and a 0.1 for another means the first is clearly better regarded.
+- Prioritize reputable news over social media noise. Items prefixed with "[low-trust]" were flagged as possibly coordinated activity; give them little weight.
+- Use evidence from every section: people discussing one party often reveal views of its rivals.
+- Don't reward a party just for having more data.
+
+Output strictly a valid JSON object:
+{
+  "standings": [{"party": string, "sentiment_score": float between -1.0 and 1.0, "emotion": one of "Strong Support", "Support", "Neutral", "Disappointment", "Anger", "Hope", "Fear", "Mockery", "summary": one sentence on why}],
+  "shared_topics": [string],
+  "notes": string
+}
+Include every party exactly once, with its name exactly as given. "shared_topics" are the 3-5 issues that come up for several parties. "notes" covers misinformation or caveats, or "None".
+
+Data to Analyze:
+%s
+`, strings.Join(names, ", "), b.String())
+
+	var raw JointResult
+	if err := generateJSON(ctx, prompt, &raw); err != nil {
+		return nil, fmt.Errorf("joint analysis failed: %w", err)
+	}
+
+	// Keep one standing per requested party, named as we know it
+	seen := make(map[string]bool)
+	for _, s := range raw.Standings {
+		for _, p := range parties {
+			if strings.EqualFold(strings.TrimSpace(s.Party), p.Name) && !seen[p.Name] {
+				seen[p.Name] = true
+				s.Party = p.Name
+				s.SentimentScore = ScoreFromSentiment(s.SentimentScore)
+				result.Standings = append(result.Standings, s)
+			}
+		}
+	}
+	if len(result.Standings) != len(parties) {
+		return nil, fmt.Errorf("joint analysis scored %d of %d parties", len(result.Standings), len(parties))
+	}
+	rankByScore(result.Standings, func(s *JointStanding) (float64, bool) { return s.SentimentScore, true }, func(s *JointStanding, rank int, _ float64) {
+		s.Rank = rank
+	})
+	sort.SliceStable(result.Standings, func(a, b int) bool { return result.Standings[a].Rank < result.Standings[b].Rank })
+
+	result.SharedTopics = raw.SharedTopics
+	result.Notes = raw.Notes
+	result.GeneratedAt = time.Now().UTC()
+	return result, nil
+}
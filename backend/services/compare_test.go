@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankByScoreSkipsPartiesWithoutSnapshots(t *testing.T) {
+	at := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	parties := []PartyLatest{
+		{Name: "DMK", Score: 55, CreatedAt: at},
+		{Name: "NTK"}, // No snapshot yet
+		{Name: "AIADMK", Score: 60, CreatedAt: at},
+	}
+	rankByScore(parties, func(p *PartyLatest) (float64, bool) { return p.Score, !p.CreatedAt.IsZero() }, func(p *PartyLatest, rank int, gap float64) {
+		p.Rank = &rank
+		p.GapToFirst = &gap
+	})
+
+	want := map[string]struct {
+		rank int
+		gap  float64
+	}{"AIADMK": {1, 0}, "DMK": {2, 5}}
+	for _, p := range parties {
+		w, ranked := want[p.Name]
+		if !ranked {
+			if p.Rank != nil || p.GapToFirst != nil {
+				t.Errorf("%s has no snapshot but was ranked", p.Name)
+			}
+			continue
+		}
+		if p.Rank == nil || *p.Rank != w.rank || *p.GapToFirst != w.gap {
+			t.Errorf("%s: rank %v gap %v, want %d and %v", p.Name, p.Rank, p.GapToFirst, w.rank, w.gap)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lowTrustTag marks items DetectInauthentic down-weighted. The prompt tells the
+// model to discount them.
+const lowTrustTag = "[low-trust] "
+
+// BuildCorpus flattens aggregated data into the text handed to the AI.
+// Items flagged for exclusion by DetectInauthentic are left out.
+func BuildCorpus(data *AggregatedData) string {
+	var b strings.Builder
+
+	b.WriteString("Latest News Headlines:\n")
+	for _, n := range data.News {
+		b.WriteString("- [" + FormatIST(n.PublishedAt) + "] " + n.Title + "\n")
+		if n.Body != "" {
+			b.WriteString("  Article: " + n.Body + "\n")
+		}
+	}
+
+	b.WriteString("\nSocial Media Comments:\n")
+	for _, c := range data.Comments {
+		if c.Suspicion >= suspicionExclude {
+			continue
+		}
+		b.WriteString("- " + trustPrefix(c.Suspicion) + c.Text + "\n")
+	}
+
+	b.WriteString("\nReddit Discussions:\n")
+	for _, p := range data.RedditPosts {
+		if p.Suspicion >= suspicionExclude {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("- %sTitle: %s\n  Body: %s\n", trustPrefix(p.Suspicion), p.Title, p.Text))
+
+		var comments []string
+		for _, c := range p.Comments {
+			if c.Suspicion >= suspicionExclude {
+				continue
+			}
+			indent := strings.Repeat("  ", c.Depth)
+			comments = append(comments, fmt.Sprintf("    %s- %s(%+d) %s\n", indent, trustPrefix(c.Suspicion), c.Score, c.Body))
+		}
+		if len(comments) > 0 {
+			b.WriteString("  Comments:\n")
+			for _, line := range comments {
+				b.WriteString(line)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func trustPrefix(suspicion float64) string {
+	if suspicion >= suspicionDownweight {
+		return lowTrustTag
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"strings"
+	"time"
+)
+
+// IST is used for display and as the assumed zone of Indian feed dates that
+// carry no offset. Everything is stored in UTC.
+var IST = time.FixedZone("IST", 5*60*60+30*60)
+
+// dateLayouts covers NewsData.io, RFC 822/1123/3339 variants and the
+// formats Tamil news feeds use once month names are translated.
+var dateLayouts = []string{
+	"2006-01-02 15:04:05", // NewsData.io
+	time.RFC3339,
+	time.RFC3339Nano,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 02 Jan 2006 15:04:05",
+	"Mon, 2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05",
+	time.RFC822Z,
+	time.RFC822,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04",
+	"January 2, 2006 15:04 -0700",
+	"January 2, 2006 3:04 PM -0700",
+	"January 2, 2006 15:04",
+	"02 January 2006 15:04 -0700",
+	"02 Jan 2006 15:04 -0700",
+	"02 Jan 2006, 15:04 -0700",
+	"02 January 2006 15:04",
+	"2 January 2006 15:04",
+	"02 Jan 2006 15:04",
+	"02 Jan 2006, 15:04",
+	"Jan 2, 2006 15:04",
+	"02/01/2006 15:04",
+	"02-01-2006 15:04",
+	"2006-01-02",
+	"January 2, 2006",
+	"2 January 2006",
+	"02 Jan 2006",
+}
+
+// tamilDateWords maps Tamil month and weekday names (as used by Dinamalar,
+// News18 Tamil etc.) to English so the layouts above can parse them.
+var tamilDateWords = strings.NewReplacer(
+	"ஜனவரி", "January",
+	"பிப்ரவரி", "February",
+	"மார்ச்", "March",
+	"ஏப்ரல்", "April",
+	"ஜூன்", "June",
+	"ஜூலை", "July",
+	"ஆகஸ்ட்", "August",
+	"செப்டம்பர்", "September",
+	"அக்டோபர்", "October",
+	"நவம்பர்", "November",
+	"டிசம்பர்", "December",
+	"மே", "May",
+	"ஞாயிறு", "Sun",
+	"திங்கள்", "Mon",
+	"செவ்வாய்", "Tue",
+	"புதன்", "Wed",
+	"வியாழன்", "Thu",
+	"வெள்ளி", "Fri",
+	"சனி", "Sat",
+)
+
+// ParseDate parses the date formats our sources emit and returns the instant
+// in UTC. Values without an explicit offset are read in loc. ok is false when
+// nothing matched; callers must then treat the date as unknown rather than
+// substituting the current time.
+func ParseDate(s string, loc *time.Location) (t time.Time, ok bool) {
+	s = normalizeDateString(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.UTC(), true
+		}
+	}
+
+	// Some feeds prepend a weekday the layouts don't expect
+	if i := strings.Index(s, ", "); i > 0 && i <= 10 {
+		return ParseDate(s[i+2:], loc)
+	}
+	return time.Time{}, false
+}
+
+func normalizeDateString(s string) string {
+	s = tamilDateWords.Replace(strings.TrimSpace(s))
+	s = strings.Join(strings.Fields(s), " ")
+
+	// Go only understands zone abbreviations it knows the offset of
+	for _, abbr := range []string{" IST", " GMT+5:30", " GMT+05:30", " UTC+05:30"} {
+		if strings.HasSuffix(s, abbr) {
+			s = strings.TrimSuffix(s, abbr) + " +0530"
+		}
+	}
+	if strings.HasSuffix(s, " GMT") || strings.HasSuffix(s, " UTC") {
+		s = s[:len(s)-4] + " +0000"
+	}
+	return s
+}
+
+// FormatIST renders t for display in Indian Standard Time.
+func FormatIST(t time.Time) string {
+	if t.IsZero() {
+		return "date unknown"
+	}
+	return t.In(IST).Format("02 Jan 2006 15:04 IST")
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		loc  *time.Location
+		want string // RFC 3339 in UTC, "" when parsing should fail
+	}{
+		{"2026-03-01 10:30:00", time.UTC, "2026-03-01T10:30:00Z"},
+		{"2026-03-01 10:30:00", IST, "2026-03-01T05:00:00Z"},
+		{"2026-03-01T10:30:00+05:30", time.UTC, "2026-03-01T05:00:00Z"},
+		{"Sun, 01 Mar 2026 10:30:00 +0530", time.UTC, "2026-03-01T05:00:00Z"},
+		{"Sun, 01 Mar 2026 10:30:00 GMT", IST, "2026-03-01T10:30:00Z"},
+		{"Sun, 1 Mar 2026 10:30:00 IST", time.UTC, "2026-03-01T05:00:00Z"},
+		{"01 Mar 2026, 10:30 GMT+05:30", time.UTC, "2026-03-01T05:00:00Z"},
+		{"  March 1, 2026   10:30  ", IST, "2026-03-01T05:00:00Z"},
+		{"01 மார்ச் 2026 10:30", IST, "2026-03-01T05:00:00Z"},
+		{"ஞாயிறு, 01 மார்ச் 2026 10:30 +0530", time.UTC, "2026-03-01T05:00:00Z"},
+		{"Updated: Sunday, March 1, 2026", IST, ""},
+		{"2026-03-01", IST, "2026-02-28T18:30:00Z"},
+		{"", time.UTC, ""},
+		{"yesterday", time.UTC, ""},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDate(tt.in, tt.loc)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("ParseDate(%q) = %v, want failure", tt.in, got)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("ParseDate(%q) failed, want %s", tt.in, tt.want)
+			continue
+		}
+		if s := got.Format(time.RFC3339); s != tt.want || got.Location() != time.UTC {
+			t.Errorf("ParseDate(%q) = %s (%v), want %s", tt.in, s, got.Location(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HTTP modes, chosen with HTTP_MODE. Record and replay store one JSON
+// "cassette" per request under HTTP_CASSETTE_DIR, so the pipeline can run
+// offline and deterministically once a live run has been recorded.
+const (
+	HTTPLive   = "live"
+	HTTPRecord = "record"
+	HTTPReplay = "replay"
+)
+
+// HTTPTransport is shared by every outbound call (feeds, NewsData, YouTube,
+// Reddit, article pages and Gemini). Set it before the first request to stub
+// the network; when nil it is picked from HTTP_MODE on first use, after
+// main has loaded .env.
+var HTTPTransport http.RoundTripper
+
+var transportOnce sync.Once
+
+// newHTTPClient returns a client on the shared transport. A zero timeout
+// leaves the deadline to the request context.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	transportOnce.Do(func() {
+		if HTTPTransport == nil {
+			HTTPTransport = transportFromEnv()
+		}
+	})
+	return &http.Client{Transport: HTTPTransport, Timeout: timeout}
+}
+
+func httpMode() string {
+	switch mode := strings.ToLower(os.Getenv("HTTP_MODE")); mode {
+	case HTTPRecord, HTTPReplay:
+		return mode
+	default:
+		return HTTPLive
+	}
+}
+
+func transportFromEnv() http.RoundTripper {
+	// Retries and breakers sit below the cassettes, so recordings hold the
+	// final response and replay never backs off
+	network := newRetryTransport(http.DefaultTransport)
+	mode := httpMode()
+	if mode == HTTPLive {
+		return network
+	}
+	dir := os.Getenv("HTTP_CASSETTE_DIR")
+	if dir == "" {
+		dir = "testdata/cassettes"
+	}
+	fmt.Printf("HTTP %s mode, cassettes in %s\n", mode, dir)
+	return &CassetteTransport{Dir: dir, Mode: mode, Next: network}
+}
+
+// apiKeyFromEnv reads a provider key. Replay never talks to the provider, so
+// a placeholder stands in for keys that aren't configured.
+func apiKeyFromEnv(name string) string {
+	key := os.Getenv(name)
+	if key == "" && httpMode() == HTTPReplay {
+		return "replay"
+	}
+	return key
+}
+
+// replayedAt is the newest recorded_at among the cassettes replayed so far.
+var replayedAt struct {
+	sync.Mutex
+	t time.Time
+}
+
+// currentTime is the clock for anything that judges age, such as news and
+// account age cutoffs. Replay runs at the time the cassettes were recorded,
+// so the same recordings give the same result whenever they are replayed.
+func currentTime() time.Time {
+	if httpMode() == HTTPReplay {
+		replayedAt.Lock()
+		defer replayedAt.Unlock()
+		if !replayedAt.t.IsZero() {
+			return replayedAt.t
+		}
+	}
+	return time.Now()
+}
+
+// secretParams are stripped from URLs before they are hashed or written to a
+// cassette, so recordings can be committed and replayed without keys.
+var secretParams = []string{"key", "apikey", "api_key"}
+
+type cassette struct {
+	Request struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+		Body   string `json:"body,omitempty"`
+	} `json:"request"`
+	Response struct {
+		Status     int         `json:"status"`
+		Header     http.Header `json:"header"`
+		Body       string      `json:"body,omitempty"`
+		BodyBase64 string      `json:"body_base64,omitempty"` // Used instead of Body for non-UTF-8 payloads
+	} `json:"response"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// CassetteTransport records request/response pairs to Dir, or serves them
+// back without touching the network. Requests match on method, URL (minus
+// secrets) and body.
+type CassetteTransport struct {
+	Dir  string
+	Mode string // HTTPRecord or HTTPReplay
+	Next http.RoundTripper
+
+	mu sync.Mutex
+}
+
+func (t *CassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	cleanURL := redactURL(req.URL)
+	path := t.path(req.Method, cleanURL, reqBody)
+
+	if t.Mode == HTTPReplay {
+		return t.replay(req, path)
+	}
+
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cassette
+	c.Request.Method = req.Method
+	c.Request.URL = cleanURL
+	c.Request.Body = string(reqBody)
+	c.Response.Status = resp.StatusCode
+	c.Response.Header = resp.Header.Clone()
+	c.Response.Header.Del("Set-Cookie")
+	// The body is stored decoded
+	c.Response.Header.Del("Content-Encoding")
+	c.Response.Header.Del("Content-Length")
+	if utf8.Valid(respBody) {
+		c.Response.Body = string(respBody)
+	} else {
+		c.Response.BodyBase64 = base64.StdEncoding.EncodeToString(respBody)
+	}
+	c.RecordedAt = time.Now().UTC()
+
+	if err := t.save(path, &c); err != nil {
+		fmt.Printf("Failed to record cassette for %s: %v\n", cleanURL, err)
+	}
+	return cassetteResponse(req, &c, respBody), nil
+}
+
+func (t *CassetteTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("no cassette for %s %s (looked for %s)", req.Method, redactURL(req.URL), path)
+	}
+	var c cassette
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("bad cassette %s: %w", path, err)
+	}
+	replayedAt.Lock()
+	if c.RecordedAt.After(replayedAt.t) {
+		replayedAt.t = c.RecordedAt
+	}
+	replayedAt.Unlock()
+
+	body := []byte(c.Response.Body)
+	if c.Response.BodyBase64 != "" {
+		if body, err = base64.StdEncoding.DecodeString(c.Response.BodyBase64); err != nil {
+			return nil, fmt.Errorf("bad cassette %s: %w", path, err)
+		}
+	}
+	return cassetteResponse(req, &c, body), nil
+}
+
+func (t *CassetteTransport) save(path string, c *cassette) error {
+	raw, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, raw, 0o644)
+}
+
+// path is <dir>/<host>/<hash>.json, grouping cassettes by provider.
+func (t *CassetteTransport) path(method, cleanURL string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + cleanURL + "\n"))
+	h.Write(body)
+	host := "unknown"
+	if u, err := url.Parse(cleanURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return filepath.Join(t.Dir, host, hex.EncodeToString(h.Sum(nil))[:16]+".json")
+}
+
+func cassetteResponse(req *http.Request, c *cassette, body []byte) *http.Response {
+	header := c.Response.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.Response.Status, http.StatusText(c.Response.Status)),
+		StatusCode:    c.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func redactURL(u *url.URL) string {
+	clean := *u
+	q := clean.Query()
+	for _, p := range secretParams {
+		q.Del(p)
+	}
+	clean.RawQuery = q.Encode()
+	return clean.String()
+}
+
+// apiKeyTransport adds a Google API key header. The Gemini SDK's REST client
+// ignores option.WithAPIKey once a custom HTTP client is supplied, so the key
+// travels with the transport too.
+type apiKeyTransport struct {
+	key  string
+	next http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("x-goog-api-key", t.key)
+	return t.next.RoundTrip(req)
+}
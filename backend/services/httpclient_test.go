@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failingTransport stands in for the network during replay.
+type failingTransport struct{ t *testing.T }
+
+func (f failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.t.Errorf("replay went to the network for %s", req.URL)
+	return nil, io.ErrUnexpectedEOF
+}
+
+func TestCassetteRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		reqBody  string
+		status   int
+		respBody []byte
+	}{
+		{"json", "GET", "/api/1/latest?q=DMK&apikey=secret-key", "", 200, []byte(`{"status":"success","results":[]}`)},
+		{"tamil text", "GET", "/rss?q=%E0%AE%A4%E0%AE%BF%E0%AE%AE%E0%AF%81%E0%AE%95&key=secret-key", "", 200, []byte("<title>திமுக</title>")},
+		{"binary body", "GET", "/image.png?api_key=secret-key", "", 200, []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}},
+		{"post body", "POST", "/v1beta/models:generateContent?key=secret-key", `{"contents":[]}`, 200, []byte(`{"candidates":[]}`)},
+		{"error status", "GET", "/youtube/v3/search?key=secret-key", "", 403, []byte(`{"error":{"code":403}}`)},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, tt := range tests {
+			if r.Method == tt.method && r.URL.String() == tt.path {
+				w.Header().Set("Set-Cookie", "session=abc")
+				w.Header().Set("X-Test", tt.name)
+				w.WriteHeader(tt.status)
+				w.Write(tt.respBody)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	record := &CassetteTransport{Dir: dir, Mode: HTTPRecord, Next: http.DefaultTransport}
+	replay := &CassetteTransport{Dir: dir, Mode: HTTPReplay, Next: failingTransport{t}}
+
+	do := func(rt http.RoundTripper, method, url, body string) (*http.Response, []byte) {
+		t.Helper()
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, url, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+		defer resp.Body.Close()
+		got, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, got
+	}
+
+	for _, tt := range tests {
+		resp, got := do(record, tt.method, server.URL+tt.path, tt.reqBody)
+		if resp.StatusCode != tt.status || !bytes.Equal(got, tt.respBody) {
+			t.Errorf("%s: recording returned %d %q", tt.name, resp.StatusCode, got)
+		}
+
+		// Replay with a different key still matches, since keys are stripped
+		replayURL := server.URL + strings.ReplaceAll(tt.path, "secret-key", "other-key")
+		resp, got = do(replay, tt.method, replayURL, tt.reqBody)
+		if resp.StatusCode != tt.status || !bytes.Equal(got, tt.respBody) {
+			t.Errorf("%s: replay returned %d %q, want %d %q", tt.name, resp.StatusCode, got, tt.status, tt.respBody)
+		}
+		if resp.Header.Get("X-Test") != tt.name || resp.Header.Get("Set-Cookie") != "" {
+			t.Errorf("%s: replay headers %v", tt.name, resp.Header)
+		}
+	}
+
+	// Nothing recorded for this one
+	req, _ := http.NewRequest("GET", server.URL+"/api/1/latest?q=AIADMK", nil)
+	if _, err := replay.RoundTrip(req); err == nil {
+		t.Error("replay of an unrecorded request succeeded")
+	}
+
+	// No cassette may hold a key
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if bytes.Contains(raw, []byte("secret-key")) {
+			t.Errorf("%s contains the API key", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayClock(t *testing.T) {
+	recorded := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	replayedAt.Lock()
+	replayedAt.t = recorded
+	replayedAt.Unlock()
+	t.Cleanup(func() {
+		replayedAt.Lock()
+		replayedAt.t = time.Time{}
+		replayedAt.Unlock()
+	})
+
+	t.Setenv("HTTP_MODE", HTTPReplay)
+	if got := currentTime(); !got.Equal(recorded) {
+		t.Errorf("currentTime() in replay = %v, want %v", got, recorded)
+	}
+	items := []NewsItem{
+		{Title: "fresh then", PublishedAt: recorded.Add(-time.Hour)},
+		{Title: "stale then", PublishedAt: recorded.Add(-72 * time.Hour)},
+	}
+	if got := filterRecentNews(items, 48*time.Hour); len(got) != 1 || got[0].Title != "fresh then" {
+		t.Errorf("filterRecentNews in replay kept %v", got)
+	}
+	if !YouTubeQuota.CanAfford(1 << 30) {
+		t.Error("replay is limited by the quota ledger")
+	}
+
+	t.Setenv("HTTP_MODE", HTTPLive)
+	if got := currentTime(); got.Before(recorded.AddDate(0, 1, 0)) {
+		t.Errorf("currentTime() live = %v, want the wall clock", got)
+	}
+}
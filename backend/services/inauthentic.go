@@ -0,0 +1,354 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Coordinated inauthentic behaviour detection.
+// Each comment/post collects a suspicion score from independent signals.
+// Items at or above suspicionDownweight are tagged as low-trust in the corpus,
+// items at or above suspicionExclude are dropped before scoring.
+const (
+	signalDuplicate     = "duplicate_text"
+	signalBurst         = "burst_timing"
+	signalNewAccount    = "new_account"
+	signalLowKarma      = "low_karma"
+	signalCrossSpam     = "cross_thread_spam"
+	suspicionDownweight = 0.5
+	suspicionExclude    = 0.9
+)
+
+var signalWeights = map[string]float64{
+	signalDuplicate:  0.6,
+	signalBurst:      0.3,
+	signalNewAccount: 0.3,
+	signalLowKarma:   0.2,
+	signalCrossSpam:  0.4,
+}
+
+const (
+	dupMinAuthors     = 3   // Distinct authors posting the same text before it counts as a cluster
+	dupMinRunes       = 15  // Shorter texts ("super", "😂") repeat naturally
+	dupSimilarity     = 0.8 // Jaccard similarity of character shingles
+	burstWindow       = 2 * time.Minute
+	burstMinItems     = 8
+	newAccountAge     = 30 * 24 * time.Hour
+	lowKarmaThreshold = 50
+	crossSpamThreads  = 3 // Same author in this many distinct videos/threads
+)
+
+type InauthenticReport struct {
+	TotalItems     int            `json:"total_items"`
+	FlaggedItems   int            `json:"flagged_items"`
+	ExcludedItems  int            `json:"excluded_items"`
+	InorganicShare float64        `json:"suspected_inorganic_share"` // Flagged / total, 0-1
+	Clusters       int            `json:"duplicate_clusters"`
+	Signals        map[string]int `json:"signals"` // Items hit by each signal
+}
+
+// socialItem is a uniform view over YouTube comments and Reddit posts and
+// comments so the signals only have to be written once. The pointers write
+// back into the source slices.
+type socialItem struct {
+	text       string
+	author     string
+	thread     string // Video ID or subreddit/post the item was posted in
+	at         time.Time
+	accountAge time.Duration // 0 if unknown
+	karma      int
+	karmaKnown bool
+	suspicion  *float64
+	flags      *[]string
+	duplicate  bool // Non-representative member of a duplicate cluster
+}
+
+func (it *socialItem) flag(signal string) {
+	for _, f := range *it.flags {
+		if f == signal {
+			return
+		}
+	}
+	*it.flags = append(*it.flags, signal)
+	*it.suspicion += signalWeights[signal]
+	if *it.suspicion > 1 {
+		*it.suspicion = 1
+	}
+}
+
+// DetectInauthentic scores every comment and post in data for signs of
+// brigading and records the result on the items themselves.
+func DetectInauthentic(data *AggregatedData) InauthenticReport {
+	now := currentTime()
+	var items []*socialItem
+
+	for i := range data.Comments {
+		c := &data.Comments[i]
+		c.Suspicion, c.Flags = 0, nil
+		author := c.AuthorChannelID
+		if author == "" {
+			author = c.Author
+		}
+		if author != "" {
+			author = "yt:" + author
+		}
+		it := &socialItem{
+			text:      c.Text,
+			author:    author,
+			thread:    "yt:" + c.VideoID,
+			at:        c.PublishedAt,
+			suspicion: &c.Suspicion,
+			flags:     &c.Flags,
+		}
+		if !c.AuthorCreatedAt.IsZero() {
+			it.accountAge = now.Sub(c.AuthorCreatedAt)
+		}
+		items = append(items, it)
+	}
+
+	for i := range data.RedditPosts {
+		p := &data.RedditPosts[i]
+		p.Suspicion, p.Flags = 0, nil
+		it := &socialItem{
+			text:      strings.TrimSpace(p.Title + " " + p.Text),
+			author:    redditAuthorKey(p.Author),
+			thread:    "r:" + strings.ToLower(p.Subreddit),
+			at:        p.CreatedAt,
+			karma:     p.AuthorKarma,
+			suspicion: &p.Suspicion,
+			flags:     &p.Flags,
+		}
+		if !p.AuthorCreatedAt.IsZero() {
+			it.accountAge = now.Sub(p.AuthorCreatedAt)
+			it.karmaKnown = true
+		}
+		items = append(items, it)
+
+		for j := range p.Comments {
+			c := &p.Comments[j]
+			c.Suspicion, c.Flags = 0, nil
+			ci := &socialItem{
+				text:      c.Body,
+				author:    redditAuthorKey(c.Author),
+				thread:    "r:post:" + p.ID,
+				at:        c.CreatedAt,
+				karma:     c.AuthorKarma,
+				suspicion: &c.Suspicion,
+				flags:     &c.Flags,
+			}
+			if !c.AuthorCreatedAt.IsZero() {
+				ci.accountAge = now.Sub(c.AuthorCreatedAt)
+				ci.karmaKnown = true
+			}
+			items = append(items, ci)
+		}
+	}
+
+	report := InauthenticReport{
+		TotalItems: len(items),
+		Signals:    make(map[string]int),
+	}
+	if len(items) == 0 {
+		return report
+	}
+
+	report.Clusters = flagDuplicates(items)
+	flagBursts(items)
+	flagAccounts(items)
+	flagCrossThreadSpam(items)
+
+	for _, it := range items {
+		if it.duplicate {
+			// Keep one copy of a copy-paste campaign in the corpus at most
+			*it.suspicion = 1
+		}
+		for _, f := range *it.flags {
+			report.Signals[f]++
+		}
+		if *it.suspicion >= suspicionDownweight {
+			report.FlaggedItems++
+		}
+		if *it.suspicion >= suspicionExclude {
+			report.ExcludedItems++
+		}
+	}
+	report.InorganicShare = float64(report.FlaggedItems) / float64(report.TotalItems)
+
+	return report
+}
+
+// flagDuplicates groups identical or near-identical texts and flags clusters
+// posted by several distinct authors. Returns the number of such clusters.
+func flagDuplicates(items []*socialItem) int {
+	norm := make([]string, len(items))
+	shingles := make([]map[string]bool, len(items))
+	for i, it := range items {
+		norm[i] = normalizeText(it.text)
+		if len([]rune(norm[i])) >= dupMinRunes {
+			shingles[i] = shingleSet(norm[i], 4)
+		}
+	}
+
+	// Union-find over similar pairs. Comment volumes per run are in the
+	// hundreds, so the quadratic comparison is fine.
+	parent := make([]int, len(items))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range items {
+		if shingles[i] == nil {
+			continue
+		}
+		for j := i + 1; j < len(items); j++ {
+			if shingles[j] == nil {
+				continue
+			}
+			if norm[i] == norm[j] || jaccard(shingles[i], shingles[j]) >= dupSimilarity {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range items {
+		if shingles[i] != nil {
+			root := find(i)
+			groups[root] = append(groups[root], i)
+		}
+	}
+
+	clusters := 0
+	for _, members := range groups {
+		authors := make(map[string]bool)
+		for _, m := range members {
+			authors[items[m].author] = true
+		}
+		if len(authors) < dupMinAuthors {
+			continue
+		}
+		clusters++
+		sort.Ints(members)
+		for k, m := range members {
+			items[m].flag(signalDuplicate)
+			if k > 0 {
+				items[m].duplicate = true
+			}
+		}
+	}
+	return clusters
+}
+
+// flagBursts flags items that arrive in an unusually dense window within the
+// same thread.
+func flagBursts(items []*socialItem) {
+	byThread := make(map[string][]*socialItem)
+	for _, it := range items {
+		if !it.at.IsZero() {
+			byThread[it.thread] = append(byThread[it.thread], it)
+		}
+	}
+
+	for _, thread := range byThread {
+		sort.Slice(thread, func(a, b int) bool { return thread[a].at.Before(thread[b].at) })
+		start := 0
+		for end := range thread {
+			for thread[end].at.Sub(thread[start].at) > burstWindow {
+				start++
+			}
+			if end-start+1 >= burstMinItems {
+				for k := start; k <= end; k++ {
+					thread[k].flag(signalBurst)
+				}
+			}
+		}
+	}
+}
+
+func flagAccounts(items []*socialItem) {
+	for _, it := range items {
+		if it.accountAge > 0 && it.accountAge < newAccountAge {
+			it.flag(signalNewAccount)
+		}
+		if it.karmaKnown && it.karma < lowKarmaThreshold {
+			it.flag(signalLowKarma)
+		}
+	}
+}
+
+// flagCrossThreadSpam flags authors who show up in many different videos or
+// threads within a single run.
+func flagCrossThreadSpam(items []*socialItem) {
+	threads := make(map[string]map[string]bool)
+	for _, it := range items {
+		if it.author == "" {
+			continue
+		}
+		if threads[it.author] == nil {
+			threads[it.author] = make(map[string]bool)
+		}
+		threads[it.author][it.thread] = true
+	}
+
+	for _, it := range items {
+		if len(threads[it.author]) >= crossSpamThreads {
+			it.flag(signalCrossSpam)
+		}
+	}
+}
+
+func redditAuthorKey(author string) string {
+	if author == "" || author == "[deleted]" {
+		return ""
+	}
+	return "r:" + author
+}
+
+// normalizeText lowercases and strips punctuation/emoji while keeping Tamil
+// combining marks, so trivially edited copies still compare equal.
+func normalizeText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			b.WriteRune(r)
+			space = false
+		case !space && b.Len() > 0:
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func shingleSet(s string, k int) map[string]bool {
+	runes := []rune(s)
+	set := make(map[string]bool)
+	for i := 0; i+k <= len(runes); i++ {
+		set[string(runes[i:i+k])] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for s := range a {
+		if b[s] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectInauthentic(t *testing.T) {
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	recent := time.Now().Add(-5 * 24 * time.Hour)
+	old := time.Now().Add(-3 * 365 * 24 * time.Hour)
+	texts := []string{
+		"The new bus routes in Coimbatore are a real help",
+		"Power cuts are back again in our area this week",
+		"Nobody talks about the price of onions anymore",
+		"Good to see the metro work finally moving ahead",
+		"Farmers in the delta still wait for their dues",
+		"Why are the roads near the bus stand still broken",
+		"Schools reopened and the new textbooks are late",
+		"Water tanker rates doubled since last summer here",
+	}
+
+	comment := func(author, video, text string, at time.Time) YouTubeComment {
+		return YouTubeComment{AuthorChannelID: author, VideoID: video, Text: text, PublishedAt: at}
+	}
+
+	tests := []struct {
+		name string
+		data AggregatedData
+		want InauthenticReport
+	}{
+		{
+			name: "nothing to score",
+			want: InauthenticReport{Signals: map[string]int{}},
+		},
+		{
+			name: "organic",
+			data: AggregatedData{Comments: []YouTubeComment{
+				comment("a", "v1", texts[0], base),
+				comment("b", "v2", texts[1], base.Add(time.Hour)),
+				comment("c", "v1", texts[2], base.Add(2*time.Hour)),
+			}},
+			want: InauthenticReport{TotalItems: 3, Signals: map[string]int{}},
+		},
+		{
+			name: "copy-paste campaign",
+			data: AggregatedData{Comments: []YouTubeComment{
+				comment("a", "v1", "Vote for change, vote for the future!!", base),
+				comment("b", "v2", "vote for change vote for the future", base.Add(10*time.Minute)),
+				comment("c", "v3", "Vote for change, vote for the future 🙏", base.Add(20*time.Minute)),
+				comment("d", "v4", texts[3], base.Add(30*time.Minute)),
+			}},
+			want: InauthenticReport{
+				TotalItems: 4, FlaggedItems: 3, ExcludedItems: 2, InorganicShare: 0.75, Clusters: 1,
+				Signals: map[string]int{signalDuplicate: 3},
+			},
+		},
+		{
+			name: "two authors repeating is not a campaign",
+			data: AggregatedData{Comments: []YouTubeComment{
+				comment("a", "v1", "Vote for change, vote for the future!!", base),
+				comment("b", "v2", "Vote for change, vote for the future!!", base.Add(10*time.Minute)),
+			}},
+			want: InauthenticReport{TotalItems: 2, Signals: map[string]int{}},
+		},
+		{
+			name: "burst in one thread",
+			data: AggregatedData{Comments: func() []YouTubeComment {
+				var cs []YouTubeComment
+				for i, text := range texts {
+					cs = append(cs, comment(fmt.Sprint("author", i), "v1", text, base.Add(time.Duration(i)*10*time.Second)))
+				}
+				return cs
+			}()},
+			want: InauthenticReport{TotalItems: 8, Signals: map[string]int{signalBurst: 8}},
+		},
+		{
+			name: "new low-karma reddit account",
+			data: AggregatedData{RedditPosts: []RedditPost{{
+				ID: "p1", Subreddit: "TamilNadu", Author: "fresh", Title: texts[4], CreatedAt: base,
+				AuthorKarma: 10, AuthorCreatedAt: recent,
+				Comments: []RedditComment{{
+					Author: "veteran", Body: texts[5], CreatedAt: base.Add(time.Hour),
+					AuthorKarma: 5000, AuthorCreatedAt: old,
+				}},
+			}}},
+			want: InauthenticReport{
+				TotalItems: 2, FlaggedItems: 1, InorganicShare: 0.5,
+				Signals: map[string]int{signalNewAccount: 1, signalLowKarma: 1},
+			},
+		},
+		{
+			name: "new account in many threads",
+			data: AggregatedData{Comments: []YouTubeComment{
+				{AuthorChannelID: "spam", VideoID: "v1", Text: texts[0], PublishedAt: base, AuthorCreatedAt: recent},
+				{AuthorChannelID: "spam", VideoID: "v2", Text: texts[1], PublishedAt: base.Add(time.Hour), AuthorCreatedAt: recent},
+				{AuthorChannelID: "spam", VideoID: "v3", Text: texts[2], PublishedAt: base.Add(2 * time.Hour), AuthorCreatedAt: recent},
+				{AuthorChannelID: "fan", VideoID: "v1", Text: texts[3], PublishedAt: base, AuthorCreatedAt: old},
+			}},
+			want: InauthenticReport{
+				TotalItems: 4, FlaggedItems: 3, InorganicShare: 0.75,
+				Signals: map[string]int{signalNewAccount: 3, signalCrossSpam: 3},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := DetectInauthentic(&tt.data)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+
+		// Running again over the same items must not stack suspicion
+		if again := DetectInauthentic(&tt.data); !reflect.DeepEqual(again, got) {
+			t.Errorf("%s: second run got %+v, want %+v", tt.name, again, got)
+		}
+	}
+}
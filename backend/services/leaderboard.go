@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+// hourlyScore is a row of the party_hourly_scores materialized view.
+type hourlyScore struct {
+	PartyID   uint
+	Hour      time.Time // UTC
+	AvgScore  float64
+	Snapshots int
+	Volume    int
+}
+
+// The view is refreshed lazily by leaderboard reads. Whether it is behind is
+// read from the database, so snapshots written by any replica (or a backfill)
+// are picked up; each process checks at most once per LEADERBOARD_REFRESH.
+var (
+	hourlyMu      sync.Mutex
+	hourlyChecked time.Time
+)
+
+// RefreshHourlyScores brings party_hourly_scores up to date if the snapshot
+// table has changed since the view was last refreshed. A count is compared
+// as well as the newest timestamp, since backfills insert and delete
+// snapshots in the past.
+func RefreshHourlyScores(ctx context.Context) error {
+	hourlyMu.Lock()
+	defer hourlyMu.Unlock()
+	if time.Since(hourlyChecked) < envDuration("LEADERBOARD_REFRESH", time.Minute) {
+		return nil
+	}
+
+	var stale bool
+	err := db.DB.WithContext(ctx).Raw(`
+		SELECT s.n <> v.n OR s.last IS DISTINCT FROM v.last
+		FROM (SELECT COUNT(*) AS n, MAX(created_at) AS last FROM sentiment_snapshots) s,
+		     (SELECT COALESCE(SUM(snapshots), 0) AS n, MAX(last_snapshot_at) AS last FROM party_hourly_scores) v`).Scan(&stale).Error
+	if err != nil {
+		return fmt.Errorf("check party_hourly_scores: %w", err)
+	}
+	if stale {
+		if err := db.DB.WithContext(ctx).Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY party_hourly_scores").Error; err != nil {
+			return fmt.Errorf("refresh party_hourly_scores: %w", err)
+		}
+	}
+	hourlyChecked = time.Now()
+	return nil
+}
+
+// LeaderboardEntry is one party's row on the leaderboard. Scores are the
+// snapshot-weighted mean over the 24 hours ending at the given time.
+type LeaderboardEntry struct {
+	PartyID     uint       `json:"party_id"`
+	Name        string     `json:"name"`
+	ColorHex    string     `json:"color_hex"`
+	Rank        int        `json:"rank"`
+	PrevRank    *int       `json:"prev_rank"`   // Rank 24h ago, nil if the party had no data then
+	RankChange  int        `json:"rank_change"` // Places gained since then, negative if lost
+	Score       *float64   `json:"score"`       // nil if the party has never been analysed
+	Delta24h    *float64   `json:"delta_24h"`
+	Delta7d     *float64   `json:"delta_7d"`
+	Volume24h   int        `json:"volume_24h"`
+	VolumeGrow  *float64   `json:"volume_growth"` // Last 24h against the daily average of the 7 days before, e.g. 0.4 = +40%
+	Velocity    *float64   `json:"velocity"`      // Score points per day, averaged over the momentum windows
+	Momentum    *float64   `json:"momentum"`      // Velocity scaled by volume growth
+	LastUpdated *time.Time `json:"last_updated"`
+	Sparkline   []*float64 `json:"sparkline"` // Daily mean score (IST), oldest first, nil on days without data
+}
+
+type Leaderboard struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	SortedBy    string             `json:"sorted_by"` // "score" or "momentum"
+	Windows     []string           `json:"momentum_windows"`
+	Days        []string           `json:"sparkline_days"` // YYYY-MM-DD, IST, aligned with each Sparkline
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// momentumWindows parses MOMENTUM_WINDOWS, e.g. "24h,7d". Go durations have
+// no day unit, so "d" is handled here.
+func momentumWindows() []time.Duration {
+	var out []time.Duration
+	for _, w := range envList("MOMENTUM_WINDOWS") {
+		if days, ok := strings.CutSuffix(w, "d"); ok {
+			if n, err := strconv.Atoi(days); err == nil && n > 0 {
+				out = append(out, time.Duration(n)*24*time.Hour)
+			}
+			continue
+		}
+		if d, err := time.ParseDuration(w); err == nil && d > 0 {
+			out = append(out, d)
+		}
+	}
+	if len(out) == 0 {
+		out = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour}
+	}
+	return out
+}
+
+func formatWindow(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	}
+	return d.String()
+}
+
+// partyHours is one party's hourly rows, oldest first.
+type partyHours []hourlyScore
+
+// scoreAt is the snapshot-weighted mean score over the 24 hours ending at t.
+func (h partyHours) scoreAt(t time.Time) *float64 {
+	sum, n := 0.0, 0
+	for _, r := range h {
+		if r.Hour.After(t.Add(-24*time.Hour)) && !r.Hour.After(t) {
+			sum += r.AvgScore * float64(r.Snapshots)
+			n += r.Snapshots
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	mean := sum / float64(n)
+	return &mean
+}
+
+// volume sums volume over [from, to).
+func (h partyHours) volume(from, to time.Time) int {
+	v := 0
+	for _, r := range h {
+		if !r.Hour.Before(from) && r.Hour.Before(to) {
+			v += r.Volume
+		}
+	}
+	return v
+}
+
+func diff(a, b *float64) *float64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	d := *a - *b
+	return &d
+}
+
+// BuildLeaderboard ranks every active party by score, or by momentum when
+// sortBy is "momentum", from the party_hourly_scores view.
+func BuildLeaderboard(ctx context.Context, sortBy string) (*Leaderboard, error) {
+	if err := RefreshHourlyScores(ctx); err != nil {
+		// Serve what the view has rather than failing
+		fmt.Printf("Leaderboard: %v\n", err)
+	}
+
+	now := time.Now().UTC()
+	windows := momentumWindows()
+	sparkDays := envInt("LEADERBOARD_SPARKLINE_DAYS", 14)
+
+	// Look back far enough for the longest window, the week-old score and
+	// the sparkline
+	lookback := 8 * 24 * time.Hour
+	for _, w := range windows {
+		lookback = max(lookback, w+24*time.Hour)
+	}
+	lookback = max(lookback, time.Duration(sparkDays+1)*24*time.Hour)
+
+	var parties []models.Party
+	if err := db.DB.WithContext(ctx).Where("active = ?", true).Order("id").Find(&parties).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []hourlyScore
+	err := db.DB.WithContext(ctx).Table("party_hourly_scores").
+		// hour has no time zone, so compare against a plain UTC timestamp
+		Where("hour >= ?", now.Add(-lookback).Format("2006-01-02 15:04:05")).Order("hour").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	byParty := make(map[uint]partyHours)
+	for _, r := range rows {
+		r.Hour = time.Date(r.Hour.Year(), r.Hour.Month(), r.Hour.Day(), r.Hour.Hour(), 0, 0, 0, time.UTC)
+		byParty[r.PartyID] = append(byParty[r.PartyID], r)
+	}
+
+	// Sparkline days, oldest first
+	today := now.In(IST)
+	todayStart := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, IST)
+	days := make([]string, sparkDays)
+	for i := range days {
+		days[i] = todayStart.AddDate(0, 0, i-sparkDays+1).Format("2006-01-02")
+	}
+
+	board := &Leaderboard{GeneratedAt: now, SortedBy: sortBy, Days: days}
+	for _, w := range windows {
+		board.Windows = append(board.Windows, formatWindow(w))
+	}
+
+	// Parties with nothing in the last day fall back to their latest
+	// snapshot so they still get a place, fetched in one query
+	var quiet []uint
+	for _, p := range parties {
+		if byParty[p.ID].scoreAt(now) == nil {
+			quiet = append(quiet, p.ID)
+		}
+	}
+	latest := make(map[uint]float64)
+	if len(quiet) > 0 {
+		var rows []struct {
+			PartyID uint
+			Score   float64
+		}
+		err := db.DB.WithContext(ctx).Raw(`
+			SELECT DISTINCT ON (party_id) party_id, score FROM sentiment_snapshots
+			WHERE party_id IN ? ORDER BY party_id, created_at DESC`, quiet).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			latest[r.PartyID] = r.Score
+		}
+	}
+
+	prevScores := make([]*float64, len(parties))
+	for i, p := range parties {
+		h := byParty[p.ID]
+		e := LeaderboardEntry{PartyID: p.ID, Name: p.Name, ColorHex: p.ColorHex}
+
+		e.Score = h.scoreAt(now)
+		if s, ok := latest[p.ID]; ok && e.Score == nil {
+			e.Score = &s
+		}
+		prevScores[i] = h.scoreAt(now.Add(-24 * time.Hour))
+		e.Delta24h = diff(e.Score, prevScores[i])
+		e.Delta7d = diff(e.Score, h.scoreAt(now.Add(-7*24*time.Hour)))
+
+		// Velocity: score change per day, averaged over the windows with data
+		sum, n := 0.0, 0
+		for _, w := range windows {
+			if d := diff(e.Score, h.scoreAt(now.Add(-w))); d != nil {
+				sum += *d / (w.Hours() / 24)
+				n++
+			}
+		}
+		if n > 0 {
+			v := sum / float64(n)
+			e.Velocity = &v
+		}
+
+		// Volume growth: last 24h against the daily average of the week before
+		e.Volume24h = h.volume(now.Add(-24*time.Hour), now.Add(time.Hour))
+		if base := float64(h.volume(now.Add(-8*24*time.Hour), now.Add(-24*time.Hour))) / 7; base > 0 {
+			g := float64(e.Volume24h)/base - 1
+			e.VolumeGrow = &g
+		}
+		if e.Velocity != nil {
+			// A move on rising volume counts for more than the same move on
+			// fading volume. Growth is capped so one viral day can't dominate.
+			mult := 1.0
+			if e.VolumeGrow != nil {
+				mult += envFloat("MOMENTUM_VOLUME_WEIGHT", 0.5) * min(max(*e.VolumeGrow, -1), 2)
+			}
+			m := *e.Velocity * mult
+			e.Momentum = &m
+		}
+
+		if len(h) > 0 {
+			last := h[len(h)-1].Hour
+			e.LastUpdated = &last
+		}
+		e.Sparkline = sparkline(h, days)
+		board.Entries = append(board.Entries, e)
+	}
+
+	// Rank changes are always by score, whatever the sort order
+	prevRanks := rankOf(prevScores)
+	curScores := make([]*float64, len(board.Entries))
+	for i := range board.Entries {
+		curScores[i] = board.Entries[i].Score
+	}
+	for i, r := range rankOf(curScores) {
+		board.Entries[i].Rank = r
+		if prevScores[i] != nil {
+			pr := prevRanks[i]
+			board.Entries[i].PrevRank = &pr
+			board.Entries[i].RankChange = pr - r
+		}
+	}
+
+	key := func(e LeaderboardEntry) *float64 { return e.Score }
+	if sortBy == "momentum" {
+		key = func(e LeaderboardEntry) *float64 { return e.Momentum }
+	}
+	sort.SliceStable(board.Entries, func(a, b int) bool {
+		ka, kb := key(board.Entries[a]), key(board.Entries[b])
+		if ka == nil || kb == nil {
+			return ka != nil
+		}
+		return *ka > *kb
+	})
+	return board, nil
+}
+
+// rankOf gives 1-based ranks by descending value. Missing values rank after
+// every present one.
+func rankOf(values []*float64) []int {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		va, vb := values[order[a]], values[order[b]]
+		if va == nil || vb == nil {
+			return va != nil
+		}
+		return *va > *vb
+	})
+	ranks := make([]int, len(values))
+	for r, i := range order {
+		ranks[i] = r + 1
+	}
+	return ranks
+}
+
+// sparkline averages the hourly rows into IST days. Hours are UTC-aligned,
+// so an hour that straddles IST midnight counts towards the day it starts in.
+func sparkline(h partyHours, days []string) []*float64 {
+	type acc struct {
+		sum float64
+		n   int
+	}
+	byDay := make(map[string]*acc)
+	for _, r := range h {
+		day := r.Hour.In(IST).Format("2006-01-02")
+		a := byDay[day]
+		if a == nil {
+			a = &acc{}
+			byDay[day] = a
+		}
+		a.sum += r.AvgScore * float64(r.Snapshots)
+		a.n += r.Snapshots
+	}
+	out := make([]*float64, len(days))
+	for i, d := range days {
+		if a := byDay[d]; a != nil && a.n > 0 {
+			mean := a.sum / float64(a.n)
+			out[i] = &mean
+		}
+	}
+	return out
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRankOf(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	tests := []struct {
+		values []*float64
+		want   []int
+	}{
+		{nil, []int{}},
+		{[]*float64{f(50), f(70), f(60)}, []int{3, 1, 2}},
+		{[]*float64{nil, f(10), nil, f(20)}, []int{3, 2, 4, 1}},
+		{[]*float64{f(5), f(5)}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		if got := rankOf(tt.values); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("rankOf(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+// LeaderScore is the model's rating of one leader in a run. LeaderID and
+// Mentions come from our own mention scan, not from the model.
+type LeaderScore struct {
+	Name           string  `json:"name"`
+	SentimentScore float64 `json:"sentiment_score"`
+	Emotion        string  `json:"emotion"`
+	LeaderID       uint    `json:"leader_id,omitempty"`
+	Mentions       int     `json:"mentions,omitempty"`
+}
+
+// LeaderMention is how many documents in a run mention a leader.
+type LeaderMention struct {
+	LeaderID  uint
+	Name      string
+	Documents int
+}
+
+// defaultLeaderAliases seeds aliases for the leaders in the default parties.
+// Short forms and Tamil spellings are how most comments refer to them.
+var defaultLeaderAliases = map[string][]string{
+	"M.K. Stalin":          {"Stalin", "MK Stalin", "ஸ்டாலின்"},
+	"Edappadi Palaniswami": {"EPS", "Palaniswami", "Edappadi", "எடப்பாடி"},
+	"Vijay":                {"Thalapathy Vijay", "TVK Vijay", "விஜய்"},
+	"K. Annamalai":         {"Annamalai", "அண்ணாமலை"},
+	"Seeman":               {"சீமான்"},
+}
+
+// EnsureDefaultLeaders creates a leader for every party whose Leader field
+// names someone we don't track yet.
+func EnsureDefaultLeaders() {
+	if db.DB == nil {
+		return
+	}
+	var parties []models.Party
+	if err := db.DB.Where("leader <> ''").Find(&parties).Error; err != nil {
+		return
+	}
+	for _, p := range parties {
+		var count int64
+		db.DB.Model(&models.Leader{}).Where("LOWER(name) = LOWER(?)", p.Leader).Count(&count)
+		if count > 0 {
+			continue
+		}
+		partyID := p.ID
+		leader := models.Leader{Name: p.Leader, Aliases: defaultLeaderAliases[p.Leader], PartyID: &partyID, Active: true}
+		if err := db.DB.Create(&leader).Error; err != nil {
+			fmt.Printf("Failed to seed leader %s: %v\n", p.Leader, err)
+		}
+	}
+}
+
+// ActiveLeaders returns the leaders to look for in documents. Without a
+// database there are none.
+func ActiveLeaders() []models.Leader {
+	if db.DB == nil {
+		return nil
+	}
+	var leaders []models.Leader
+	if err := db.DB.Where("active = ?", true).Order("id").Find(&leaders).Error; err != nil {
+		fmt.Printf("Failed to load leaders: %v\n", err)
+	}
+	return leaders
+}
+
+// Tamil case suffixes. A name ending in a consonant (ஸ்டாலின், விஜய்) drops
+// its pulli for a vowel-led suffix (ஸ்டாலினை, ஸ்டாலினுக்கு), keeps it before
+// a consonant-led one (விஜய்க்கு), or doubles the consonant (விஜய்யை). A name
+// ending in a vowel (எடப்பாடி) takes a glide first (எடப்பாடியை). Only these
+// endings are allowed, so விஜய் doesn't match விஜயகாந்த்.
+var (
+	tamilVowelSuffixes = []string{
+		"ை", "ைப்", "ைக்", "ைச்", "ின்", "ின", "ினை", "ினால்", "ிற்கு", "ிடம்", "ிடமிருந்து",
+		"ுக்கு", "ுக்குப்", "ுக்கும்", "ுடன்", "ுடைய", "ும்", "ோடு", "ோ", "ால்", "ாலும்", "ே", "ா", "து",
+	}
+	tamilConsonantSuffixes = []string{"க்கு", "க்குப்", "க்கும்", "கு"}
+	tamilGlideSuffixes     = []string{
+		"யை", "யைப்", "யின்", "யிடம்", "யுடன்", "யுடைய", "யும்", "யால்", "யோடு", "யே", "யா",
+		"க்கு", "க்குப்", "க்கும்", "வை", "வின்", "வுக்கு", "வுடன்", "வும்", "வால்", "வே",
+	}
+)
+
+func quoteAll(list []string) string {
+	q := make([]string, len(list))
+	for i, s := range list {
+		q[i] = regexp.QuoteMeta(s)
+	}
+	return strings.Join(q, "|")
+}
+
+// tamilForms is a pattern for a Tamil name and its inflected forms.
+func tamilForms(name string) string {
+	const pulli = "\u0BCD"
+	stem, ok := strings.CutSuffix(name, pulli)
+	if !ok {
+		return regexp.QuoteMeta(name) + `(?:` + quoteAll(tamilGlideSuffixes) + `)?`
+	}
+	r := []rune(stem)
+	last := string(r[len(r)-1])
+	vowel := quoteAll(tamilVowelSuffixes)
+	return regexp.QuoteMeta(stem) + `(?:` + vowel + `|` + pulli + `(?:` + quoteAll(tamilConsonantSuffixes) +
+		`|` + regexp.QuoteMeta(last) + `(?:` + vowel + `))?)`
+}
+
+// leaderPattern matches any of the leader's names as a whole word. \b only
+// knows ASCII, so word edges are spelled out to cover Tamil script as well.
+// Tamil names also match their case-inflected forms (see tamilForms).
+func leaderPattern(l models.Leader) *regexp.Regexp {
+	var names []string
+	for _, n := range append([]string{l.Name}, l.Aliases...) {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			continue
+		}
+		if isASCII(n) {
+			names = append(names, regexp.QuoteMeta(n))
+		} else {
+			names = append(names, tamilForms(n))
+		}
+	}
+	const edge = `[^\p{L}\p{M}\p{N}]`
+	return regexp.MustCompile(`(?i)(?:^|` + edge + `)(?:` + strings.Join(names, "|") + `)(?:$|` + edge + `)`)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// documentTexts lists the text of every document that goes into the corpus,
+// one entry per news item, comment, post and Reddit comment.
+func documentTexts(data *AggregatedData) []string {
+	var docs []string
+	for _, n := range data.News {
+		docs = append(docs, n.Title+"\n"+n.Description+"\n"+n.Body)
+	}
+	for _, c := range data.Comments {
+		if c.Suspicion < suspicionExclude {
+			docs = append(docs, c.Text)
+		}
+	}
+	for _, p := range data.RedditPosts {
+		if p.Suspicion >= suspicionExclude {
+			continue
+		}
+		docs = append(docs, p.Title+"\n"+p.Text)
+		for _, c := range p.Comments {
+			if c.Suspicion < suspicionExclude {
+				docs = append(docs, c.Body)
+			}
+		}
+	}
+	return docs
+}
+
+// FindLeaderMentions counts the documents that mention each leader. Leaders
+// with fewer than LEADER_MIN_MENTIONS (default 3) are left out, since a
+// couple of passing mentions are not enough to rate someone on.
+func FindLeaderMentions(data *AggregatedData, leaders []models.Leader) []LeaderMention {
+	if len(leaders) == 0 {
+		return nil
+	}
+	docs := documentTexts(data)
+	floor := envInt("LEADER_MIN_MENTIONS", 3)
+
+	var mentions []LeaderMention
+	for _, l := range leaders {
+		re := leaderPattern(l)
+		n := 0
+		for _, d := range docs {
+			if re.MatchString(d) {
+				n++
+			}
+		}
+		if n >= floor {
+			mentions = append(mentions, LeaderMention{LeaderID: l.ID, Name: l.Name, Documents: n})
+		}
+	}
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].Documents > mentions[j].Documents })
+	return mentions
+}
+
+// matchLeaderScores ties the model's leader ratings back to the mention scan
+// by name. Names the model made up or mangled are dropped.
+func matchLeaderScores(scores []LeaderScore, mentions []LeaderMention) []LeaderScore {
+	byName := make(map[string]LeaderMention, len(mentions))
+	for _, m := range mentions {
+		byName[strings.ToLower(m.Name)] = m
+	}
+	var out []LeaderScore
+	seen := make(map[uint]bool)
+	for _, s := range scores {
+		m, ok := byName[strings.ToLower(strings.TrimSpace(s.Name))]
+		if !ok || seen[m.LeaderID] {
+			continue
+		}
+		seen[m.LeaderID] = true
+		s.Name = m.Name
+		s.LeaderID = m.LeaderID
+		s.Mentions = m.Documents
+		out = append(out, s)
+	}
+	return out
+}
+
+// NewLeaderSnapshots turns the leader ratings of an analysis into rows for
+// the given party snapshot. Scores are expected in the raw -1..1 range.
+func NewLeaderSnapshots(analysis *AIAnalysisResult, snapshot models.SentimentSnapshot) []models.LeaderSnapshot {
+	var rows []models.LeaderSnapshot
+	for _, s := range analysis.LeaderScores {
+		snapshotID := snapshot.ID
+		rows = append(rows, models.LeaderSnapshot{
+			LeaderID:   s.LeaderID,
+			PartyID:    snapshot.PartyID,
+			SnapshotID: &snapshotID,
+			Score:      ScoreFromSentiment(s.SentimentScore),
+			Emotion:    s.Emotion,
+			Mentions:   s.Mentions,
+			Backfilled: snapshot.Backfilled,
+			CreatedAt:  snapshot.CreatedAt,
+		})
+	}
+	return rows
+}
+
+// LeaderPoint is one IST day of a leader's rating next to their party's.
+type LeaderPoint struct {
+	Date       string   `json:"date"` // YYYY-MM-DD, IST
+	Score      float64  `json:"score"`
+	Mentions   int      `json:"mentions"`
+	PartyScore *float64 `json:"party_score"` // nil when the party has no snapshot that day
+	Gap        *float64 `json:"gap"`         // Score minus PartyScore
+}
+
+type LeaderSeries struct {
+	LeaderID uint          `json:"leader_id"`
+	Name     string        `json:"name"`
+	PartyID  *uint         `json:"party_id"`
+	Points   []LeaderPoint `json:"points"`
+}
+
+// LeaderHistory averages a leader's snapshots per IST day, mention-weighted,
+// and lines them up with the daily mean of their own party's snapshots.
+func LeaderHistory(leaderID uint, from, to time.Time) (*LeaderSeries, error) {
+	var leader models.Leader
+	if err := db.DB.First(&leader, leaderID).Error; err != nil {
+		return nil, fmt.Errorf("leader %d: %w", leaderID, err)
+	}
+	series := &LeaderSeries{LeaderID: leader.ID, Name: leader.Name, PartyID: leader.PartyID, Points: []LeaderPoint{}}
+
+	var rows []models.LeaderSnapshot
+	err := db.DB.Where("leader_id = ? AND created_at >= ? AND created_at < ?", leader.ID, from.UTC(), to.UTC()).
+		Order("created_at").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type acc struct {
+		sum      float64
+		weight   float64
+		mentions int
+	}
+	byDay := make(map[string]*acc)
+	for _, r := range rows {
+		day := r.CreatedAt.In(IST).Format("2006-01-02")
+		a := byDay[day]
+		if a == nil {
+			a = &acc{}
+			byDay[day] = a
+		}
+		w := float64(r.Mentions)
+		if w <= 0 {
+			w = 1
+		}
+		a.sum += w * r.Score
+		a.weight += w
+		a.mentions += r.Mentions
+	}
+
+	partyByDay := make(map[string]float64)
+	if leader.PartyID != nil {
+		var snapshots []models.SentimentSnapshot
+		db.DB.Select("score", "created_at").
+			Where("party_id = ? AND created_at >= ? AND created_at < ?", *leader.PartyID, from.UTC(), to.UTC()).
+			Find(&snapshots)
+		counts := make(map[string]int)
+		for _, s := range snapshots {
+			day := s.CreatedAt.In(IST).Format("2006-01-02")
+			partyByDay[day] += s.Score
+			counts[day]++
+		}
+		for day, n := range counts {
+			partyByDay[day] /= float64(n)
+		}
+	}
+
+	days := make([]string, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	for _, day := range days {
+		a := byDay[day]
+		point := LeaderPoint{Date: day, Score: a.sum / a.weight, Mentions: a.mentions}
+		if ps, ok := partyByDay[day]; ok {
+			gap := point.Score - ps
+			point.PartyScore = &ps
+			point.Gap = &gap
+		}
+		series.Points = append(series.Points, point)
+	}
+	return series, nil
+}
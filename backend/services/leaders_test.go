@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+
+	"election-pulse-backend/models"
+)
+
+func TestLeaderPattern(t *testing.T) {
+	vijay := models.Leader{Name: "Vijay", Aliases: models.StringList{"விஜய்"}}
+	stalin := models.Leader{Name: "M. K. Stalin", Aliases: models.StringList{"Stalin", "ஸ்டாலின்"}}
+	eps := models.Leader{Name: "Edappadi Palaniswami", Aliases: models.StringList{"EPS", "எடப்பாடி"}}
+
+	tests := []struct {
+		leader models.Leader
+		text   string
+		want   bool
+	}{
+		{vijay, "Vijay held a rally in Madurai", true},
+		{vijay, "vijay's speech", true},
+		{vijay, "Vijayakanth's party", false},
+		{vijay, "விஜய் மாநாடு", true},
+		{vijay, "விஜய்க்கு ஆதரவு", true},
+		{vijay, "விஜய்யை சந்தித்தார்", true},
+		{vijay, "விஜயின் பேச்சு", true},
+		{vijay, "விஜயகாந்த் நினைவு நாள்", false},
+		{vijay, "விஜயகாந்தின் கட்சி", false},
+		{vijay, "அமைச்சர் விஜயபாஸ்கர் பேட்டி", false},
+
+		{stalin, "Stalin inaugurated the bridge", true},
+		{stalin, "Stalinist policies", false},
+		{stalin, "ஸ்டாலின் அறிவிப்பு", true},
+		{stalin, "ஸ்டாலினை விமர்சித்தார்", true},
+		{stalin, "ஸ்டாலினின் திட்டம்", true},
+		{stalin, "ஸ்டாலினுக்கு நன்றி", true},
+		{stalin, "(ஸ்டாலினும்)", true},
+
+		{eps, "EPS slams the government", true},
+		{eps, "30 FPS, REPS and STEPS", false},
+		{eps, "எடப்பாடி பழனிசாமி", true},
+		{eps, "எடப்பாடியை சந்தித்தனர்", true},
+		{eps, "எடப்பாடிக்கு எதிர்ப்பு", true},
+	}
+	for _, tt := range tests {
+		if got := leaderPattern(tt.leader).MatchString(tt.text); got != tt.want {
+			t.Errorf("%s in %q: got %v, want %v", tt.leader.Name, tt.text, got, tt.want)
+		}
+	}
+}
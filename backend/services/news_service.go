@@ -2,46 +2,51 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"time"
 
+	"election-pulse-backend/db"
+
 	"github.com/mmcdole/gofeed"
 )
 
 type NewsItem struct {
 	Title       string
 	Link        string
-	PublishedAt time.Time
+	Description string    // Feed or API summary, may contain HTML
+	Content     string    // Full feed content (content:encoded), may contain HTML
+	Body        string    // Plain-text article body, filled by EnrichArticles
+	PublishedAt time.Time // UTC, zero when DateUnknown
+	DateUnknown bool      // The source gave no parseable date
 	Source      string
+	SourceName  string   // Publisher name when the source reports one
+	SourceURL   string   // Publisher home page when the source reports one
+	Categories  []string // Provider categories, e.g. "politics"
+	Language    string
+	TargetID    uint // source_targets row the item came from, 0 if none
+	FeedItemID  uint // feed_items row, so extracted bodies can be cached
 }
 
 func FetchNews(ctx context.Context, query string) ([]NewsItem, error) {
-	// RSS Sources
-	// 1. Google News (Tamil Nadu context)
+	// RSS Sources come from source_targets, e.g.
+	// 1. Google News (Tamil Nadu context, templated with the query)
 	// 2. Dinamalar (Front Page)
 	// 3. Dinakaran (Latest)
+	targets := ResolveTargets(TargetRSS, partyIDByName(query), "")
 
-	urls := []struct {
-		URL    string
-		Source string
-	}{
-		{
-			URL:    fmt.Sprintf("https://news.google.com/rss/search?q=%s&hl=ta&gl=IN&ceid=IN:ta", query),
-			Source: "Google News",
-		},
-		{
-			URL:    "https://feeds.feedburner.com/dinamalar/Front_page_news",
-			Source: "Dinamalar",
-		},
-		{
-			URL:    "https://tamil.hindustantimes.com/rss/tamilnadu",
-			Source: "Hindustan Times Tamil",
-		},
-		{
-			URL:    "https://tamil.news18.com/commonfeeds/v1/tam/rss/tamil-nadu.xml",
-			Source: "News18 Tamil Nadu",
-		},
+	type feedInfo struct {
+		URL      string
+		Source   string
+		TargetID uint
+	}
+	var urls []feedInfo
+	for _, t := range targets {
+		source := t.Label
+		if source == "" {
+			source = t.Value
+		}
+		urls = append(urls, feedInfo{URL: expandTarget(t.Value, query), Source: source, TargetID: t.ID})
 	}
 
 	// Channel to collect results
@@ -52,9 +57,9 @@ func FetchNews(ctx context.Context, query string) ([]NewsItem, error) {
 	resultChan := make(chan result, len(urls))
 
 	for _, u := range urls {
-		go func(urlInfo struct{ URL, Source string }) {
+		go func(urlInfo feedInfo) {
 			// Fetch with explicit headers
-			items, err := fetchFeedItems(ctx, urlInfo.URL, urlInfo.Source)
+			items, err := fetchFeedItems(ctx, urlInfo.URL, urlInfo.Source, urlInfo.TargetID)
 			if err != nil {
 				// Log error but don't fail everything
 				fmt.Printf("Error fetching %s: %v\n", urlInfo.Source, err)
@@ -66,69 +71,82 @@ func FetchNews(ctx context.Context, query string) ([]NewsItem, error) {
 	}
 
 	var allItems []NewsItem
+	var errs []error
 	for i := 0; i < len(urls); i++ {
 		res := <-resultChan
-		if res.items != nil {
-			allItems = append(allItems, res.items...)
+		if res.err != nil {
+			errs = append(errs, res.err)
+			continue
 		}
+		allItems = append(allItems, res.items...)
 	}
 
+	// Some dead feeds are normal; all of them failing means RSS is down
+	if len(urls) > 0 && len(errs) == len(urls) {
+		return nil, fmt.Errorf("all %d feeds failed: %w", len(urls), errors.Join(errs...))
+	}
 	return allItems, nil
 }
 
-func fetchFeedItems(ctx context.Context, url, source string) ([]NewsItem, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	// Set headers to mimic a real browser
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Referer", "https://www.google.com/")
-	req.Header.Set("Connection", "keep-alive")
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// perFeedLimit is how many of a feed's latest items go into one analysis.
+const perFeedLimit = 5
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("http error: %s", resp.Status)
+func fetchFeedItems(ctx context.Context, url, source string, targetID uint) ([]NewsItem, error) {
+	if db.DB != nil {
+		return ingestFeed(ctx, url, source, targetID)
 	}
 
-	fp := gofeed.NewParser()
-	feed, err := fp.Parse(resp.Body)
+	// No registry to remember state in, so just fetch the feed in full
+	res, err := fetchFeed(ctx, url, "", "")
 	if err != nil {
 		return nil, err
 	}
 
 	var items []NewsItem
-	for _, item := range feed.Items {
-		pubDate := ioTime(item.PublishedParsed)
+	for _, item := range res.feed.Items {
+		pubDate, ok := feedItemDate(item)
 		items = append(items, NewsItem{
 			Title:       item.Title,
 			Link:        item.Link,
+			Description: item.Description,
+			Content:     item.Content,
 			PublishedAt: pubDate,
+			DateUnknown: !ok,
 			Source:      source,
+			TargetID:    targetID,
 		})
 		// Limit per source
-		if len(items) >= 5 {
+		if len(items) >= perFeedLimit {
 			break
 		}
 	}
 	return items, nil
 }
 
-func ioTime(t *time.Time) time.Time {
-	if t != nil {
-		return *t
+// feedItemDate prefers gofeed's parsed dates and falls back to our own parser
+// for locale-specific formats it doesn't understand (e.g. Tamil month names).
+func feedItemDate(item *gofeed.Item) (time.Time, bool) {
+	if item.PublishedParsed != nil {
+		return item.PublishedParsed.UTC(), true
+	}
+	if t, ok := ParseDate(item.Published, IST); ok {
+		return t, true
+	}
+	if item.UpdatedParsed != nil {
+		return item.UpdatedParsed.UTC(), true
+	}
+	return ParseDate(item.Updated, IST)
+}
+
+// filterRecentNews drops items older than maxAge. Undated items are kept;
+// they are marked as such in the corpus instead of posing as fresh.
+func filterRecentNews(items []NewsItem, maxAge time.Duration) []NewsItem {
+	cutoff := currentTime().Add(-maxAge)
+	var recent []NewsItem
+	for _, it := range items {
+		if it.DateUnknown || it.PublishedAt.After(cutoff) {
+			recent = append(recent, it)
+		}
 	}
-	return time.Now()
+	return recent
 }
@@ -6,84 +6,266 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
 	"time"
+
+	"election-pulse-backend/models"
 )
 
 type NewsDataResponse struct {
-	Status       string           `json:"status"`
-	TotalResults int              `json:"totalResults"`
-	Results      []NewsDataResult `json:"results"`
+	Status       string          `json:"status"`
+	TotalResults int             `json:"totalResults"`
+	Results      json.RawMessage `json:"results"` // Article list, or an error object when status is "error"
+	NextPage     string          `json:"nextPage"`
 }
 
 type NewsDataResult struct {
-	Title       string `json:"title"`
-	Link        string `json:"link"`
-	PubDate     string `json:"pubDate"` // standard pubdate
-	SourceID    string `json:"source_id"`
-	Description string `json:"description"`
+	ArticleID   string   `json:"article_id"`
+	Title       string   `json:"title"`
+	Link        string   `json:"link"`
+	PubDate     string   `json:"pubDate"`   // "2006-01-02 15:04:05"
+	PubDateTZ   string   `json:"pubDateTZ"` // Zone of PubDate, normally "UTC"
+	SourceID    string   `json:"source_id"`
+	SourceName  string   `json:"source_name"`
+	SourceURL   string   `json:"source_url"`
+	Description string   `json:"description"`
+	Content     string   `json:"content"` // Only populated on paid plans
+	Category    []string `json:"category"`
+	Language    string   `json:"language"`
+}
+
+type newsDataError struct {
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// NewsData.io endpoints. /latest covers roughly the last 48 hours; /archive
+// takes a date range and is what backfills use.
+const (
+	NewsDataLatest  = "latest"
+	NewsDataArchive = "archive"
+)
+
+// newsDataPaidOnly is what the API puts in fields the plan doesn't include.
+const newsDataPaidOnly = "ONLY AVAILABLE IN PAID PLANS"
+
+// NewsDataCredits tracks API credits; every request costs one regardless of
+// how many articles it returns. The free plan allows 200 a day.
+var NewsDataCredits = &QuotaLedger{
+	Service:    "newsdata",
+	DailyLimit: 200,
+	LimitEnv:   "NEWSDATA_DAILY_CREDITS",
+	Location:   time.UTC,
+}
+
+type NewsDataOptions struct {
+	Mode           string    // NewsDataLatest or NewsDataArchive
+	From, To       time.Time // Archive mode only
+	MaxPages       int       // Pages (and credits) to spend following nextPage
+	Categories     []string  // e.g. "politics", at most 5
+	Domains        []string  // Restrict to these domains, at most 5
+	ExcludeDomains []string
+}
+
+// defaultNewsDataOptions reads the live-run settings from the environment.
+// news_domain targets aren't added to Domains: domainurl is a hard filter
+// and only takes 5, so they just tag the articles served from them.
+func defaultNewsDataOptions() NewsDataOptions {
+	return NewsDataOptions{
+		Mode:           NewsDataLatest,
+		MaxPages:       envInt("NEWSDATA_MAX_PAGES", 2),
+		Categories:     envList("NEWSDATA_CATEGORIES"),
+		Domains:        envList("NEWSDATA_DOMAINS"),
+		ExcludeDomains: envList("NEWSDATA_EXCLUDE_DOMAINS"),
+	}
 }
 
 func FetchNewsData(ctx context.Context, query string) ([]NewsItem, error) {
-	apiKey := os.Getenv("NEWSDATA_API_KEY")
+	return FetchNewsDataWithOptions(ctx, query, defaultNewsDataOptions())
+}
+
+// FetchNewsDataWithOptions pages through NewsData.io results up to
+// opts.MaxPages, stopping early when credits for the day run out.
+func FetchNewsDataWithOptions(ctx context.Context, query string, opts NewsDataOptions) ([]NewsItem, error) {
+	apiKey := apiKeyFromEnv("NEWSDATA_API_KEY")
 	if apiKey == "" {
-		// Fallback or just return empty if not configured (optional source)
-		fmt.Println("NEWSDATA_API_KEY not set, skipping NewsData.io fetch")
-		return nil, nil
+		// Optional source, reported as skipped rather than failed
+		return nil, fmt.Errorf("%w: NEWSDATA_API_KEY not set", errSkipped)
 	}
-	fmt.Printf("Fetching NewsData.io for query: %s\n", query)
+	fmt.Printf("Fetching NewsData.io (%s) for query: %s\n", opts.Mode, query)
+	defer NewsDataCredits.Flush()
 
 	// Build URL
-	baseURL := "https://newsdata.io/api/1/news"
 	params := url.Values{}
 	params.Add("apikey", apiKey)
 	params.Add("q", query)
 	params.Add("language", "ta,en") // Tamil and English
 	params.Add("country", "in")     // India context
+	if len(opts.Categories) > 0 {
+		params.Add("category", strings.Join(firstN(opts.Categories, 5, "categories"), ","))
+	}
+	if len(opts.Domains) > 0 {
+		params.Add("domainurl", strings.Join(firstN(opts.Domains, 5, "domains"), ","))
+	}
+	if len(opts.ExcludeDomains) > 0 {
+		params.Add("excludedomain", strings.Join(firstN(opts.ExcludeDomains, 5, "excluded domains"), ","))
+	}
+
+	endpoint := NewsDataLatest
+	if opts.Mode == NewsDataArchive {
+		endpoint = NewsDataArchive
+		params.Add("from_date", opts.From.UTC().Format("2006-01-02"))
+		params.Add("to_date", opts.To.UTC().Format("2006-01-02"))
+	}
+	baseURL := "https://newsdata.io/api/1/" + endpoint
+
+	targets := ResolveTargets(TargetNewsDomain, partyIDByName(query), "")
+
+	client := newHTTPClient(10 * time.Second)
+	seen := make(map[string]bool)
+	var items []NewsItem
+	page := ""
+
+	for p := 0; p < max(opts.MaxPages, 1); p++ {
+		if !NewsDataCredits.CanAfford(1) {
+			if len(items) == 0 {
+				return nil, fmt.Errorf("newsdata daily credits exhausted")
+			}
+			fmt.Println("NewsData.io credits exhausted, stopping pagination")
+			break
+		}
+		if page != "" {
+			params.Set("page", page)
+		}
+
+		data, err := newsDataRequest(ctx, client, fmt.Sprintf("%s?%s", baseURL, params.Encode()))
+		NewsDataCredits.Spend(endpoint, 1)
+		if err != nil {
+			if len(items) == 0 {
+				return nil, err
+			}
+			fmt.Printf("NewsData.io page %d failed, keeping earlier pages: %v\n", p+1, err)
+			break
+		}
 
-	reqURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+		var results []NewsDataResult
+		if err := json.Unmarshal(data.Results, &results); err != nil {
+			return items, fmt.Errorf("failed to decode newsdata results: %w", err)
+		}
 
+		for _, res := range results {
+			key := res.ArticleID
+			if key == "" {
+				key = res.Link
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			items = append(items, newsDataItem(res, targets))
+		}
+
+		if data.NextPage == "" {
+			break
+		}
+		page = data.NextPage
+	}
+
+	fmt.Printf("Fetched %d items from NewsData.io\n", len(items))
+	return items, nil
+}
+
+func newsDataRequest(ctx context.Context, client *http.Client, reqURL string) (*NewsDataResponse, error) {
 	// Create request
 	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
+	var data NewsDataResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&data)
+
+	if resp.StatusCode != 200 || data.Status == "error" {
+		var apiErr newsDataError
+		if decodeErr == nil && json.Unmarshal(data.Results, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("newsdata api error: %s (%s)", apiErr.Message, apiErr.Code)
+		}
 		return nil, fmt.Errorf("newsdata api error: %s", resp.Status)
 	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	return &data, nil
+}
 
-	var data NewsDataResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, err
+// newsDataItem maps an API result into the shared news item model.
+func newsDataItem(res NewsDataResult, targets []models.SourceTarget) NewsItem {
+	pubDate, ok := ParseDate(res.PubDate, newsDataZone(res.PubDateTZ))
+
+	content := res.Content
+	if content == newsDataPaidOnly {
+		content = ""
+	}
+	description := res.Description
+	if description == newsDataPaidOnly {
+		description = ""
 	}
 
-	var items []NewsItem
-	for _, res := range data.Results {
-		// parser time
-		// Example: "2023-10-27 10:00:00" or similar? Need to check docs or allow permissive parsing.
-		// NewsData often sends "2021-01-29 16:34:02" standard SQL format or RFC.
-		// Let's try flexible parsing or default to Now.
+	return NewsItem{
+		Title:       res.Title,
+		Link:        res.Link,
+		Description: description,
+		Content:     content,
+		PublishedAt: pubDate,
+		DateUnknown: !ok,
+		Source:      "NewsData_" + res.SourceID,
+		SourceName:  res.SourceName,
+		SourceURL:   res.SourceURL,
+		Categories:  res.Category,
+		Language:    res.Language,
+		TargetID:    matchDomainTarget(targets, res.Link, res.SourceURL),
+	}
+}
 
-		// Attempt parsing standard layouts
-		pubDate := time.Now()
-		// Try a few formats if needed, or ioTime helper if adapted.
+// firstN trims a filter list to the API's limit, logging what was dropped.
+func firstN(values []string, n int, what string) []string {
+	if len(values) <= n {
+		return values
+	}
+	fmt.Printf("NewsData: only the first %d of %d %s are used\n", n, len(values), what)
+	return values[:n]
+}
 
-		items = append(items, NewsItem{
-			Title:       res.Title,
-			Link:        res.Link,
-			PublishedAt: pubDate, // simplified for now
-			Source:      "NewsData_" + res.SourceID,
-		})
+// matchDomainTarget finds the news_domain target an article was served from.
+func matchDomainTarget(domains []models.SourceTarget, links ...string) uint {
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+		for _, d := range domains {
+			domain := strings.TrimPrefix(strings.ToLower(d.Value), "www.")
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return d.ID
+			}
+		}
 	}
+	return 0
+}
 
-	fmt.Printf("Fetched %d items from NewsData.io\n", len(items))
-	return items, nil
+func newsDataZone(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	return time.UTC
 }
@@ -2,78 +2,251 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 )
 
 type AggregatedData struct {
 	News        []NewsItem
 	Comments    []YouTubeComment
 	RedditPosts []RedditPost
+	Inauthentic InauthenticReport
+	Sources     []SourceResult
+	Coverage    Coverage
 }
 
+// Source outcomes
+const (
+	SourceOK      = "ok"
+	SourceFailed  = "failed"
+	SourceSkipped = "skipped"
+)
+
+// errSkipped marks a source that was deliberately not called (open breaker,
+// no quota, no API key) as opposed to one that failed.
+var errSkipped = errors.New("skipped")
+
+// SourceResult is how one source fared in a run.
+type SourceResult struct {
+	Source    string `json:"source"`
+	Status    string `json:"status"` // "ok", "failed" or "skipped"
+	Items     int    `json:"items"`  // Fetched before filtering; a failed source may still return some
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// sourceTimeout is the deadline for one source: SOURCE_TIMEOUT_<NAME> if set,
+// otherwise SOURCE_TIMEOUT.
+func sourceTimeout(name string) time.Duration {
+	return envDuration("SOURCE_TIMEOUT_"+strings.ToUpper(name), envDuration("SOURCE_TIMEOUT", 20*time.Second))
+}
+
+// runSource runs fetch under a deadline, times it and turns its outcome into
+// a SourceResult.
+func runSource(ctx context.Context, name string, timeout time.Duration, fetch func(ctx context.Context) (int, error)) SourceResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	items, err := fetch(ctx)
+	res := SourceResult{
+		Source:    name,
+		Status:    SourceOK,
+		Items:     items,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		res.Status = SourceFailed
+		if errors.Is(err, errSkipped) {
+			res.Status = SourceSkipped
+		}
+		res.Error = err.Error()
+		fmt.Printf("%s fetch %s: %v\n", name, res.Status, err)
+	}
+	return res
+}
+
+// FetchAllData queries every source in parallel. Each source has its own
+// deadline and the whole fetch stage is bounded by FETCH_TIMEOUT; when that
+// passes, whatever has arrived is used and late sources are reported as
+// failed. The caller's ctx still bounds the later stages.
 func FetchAllData(ctx context.Context, partyName string) (*AggregatedData, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, envDuration("FETCH_TIMEOUT", 45*time.Second))
+	defer cancel()
+
 	var wg sync.WaitGroup
-	var mu sync.Mutex // Mutex for shared data.News access
+	var mu sync.Mutex // Guards data and results, and done once we stop waiting
 	var data AggregatedData
-	var newsErr, youtubeErr, redditErr, newsDataErr error
+	names := []string{SourceRSS, SourceNewsData, SourceYouTube, SourceReddit}
+	results := make([]SourceResult, len(names))
+	done := false
+
+	// finish stores a source's output unless the run has already moved on
+	finish := func(i int, res SourceResult, store func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			fmt.Printf("%s returned after the fetch deadline, dropping its results\n", names[i])
+			return
+		}
+		results[i] = res
+		store()
+	}
 
-	wg.Add(4)
+	wg.Add(len(names))
 
 	// Fetch News (RSS)
 	go func() {
 		defer wg.Done()
-		fmt.Println("Starting RSS News fetch...")
-		rssItems, err := FetchNews(ctx, partyName)
-		newsErr = err
-
-		mu.Lock()
-		if rssItems != nil {
-			data.News = append(data.News, rssItems...)
-		}
-		mu.Unlock()
+		var rssItems []NewsItem
+		res := runSource(fetchCtx, SourceRSS, sourceTimeout(SourceRSS), func(ctx context.Context) (int, error) {
+			fmt.Println("Starting RSS News fetch...")
+			var err error
+			rssItems, err = FetchNews(ctx, partyName)
+			return len(rssItems), err
+		})
+		finish(0, res, func() { data.News = append(data.News, rssItems...) })
 	}()
 
 	// Fetch NewsData.io (API)
 	go func() {
 		defer wg.Done()
-		fmt.Println("Starting NewsData.io fetch...")
-		apiItems, err := FetchNewsData(ctx, partyName)
-		newsDataErr = err
-
-		mu.Lock()
-		if apiItems != nil {
-			data.News = append(data.News, apiItems...)
-		}
-		mu.Unlock()
+		var apiItems []NewsItem
+		res := runSource(fetchCtx, SourceNewsData, sourceTimeout(SourceNewsData), func(ctx context.Context) (int, error) {
+			if Breaker(SourceNewsData).IsOpen() {
+				return 0, fmt.Errorf("%w: %s circuit open", errSkipped, SourceNewsData)
+			}
+			fmt.Println("Starting NewsData.io fetch...")
+			var err error
+			apiItems, err = FetchNewsData(ctx, partyName)
+			return len(apiItems), err
+		})
+		finish(1, res, func() { data.News = append(data.News, apiItems...) })
 	}()
 
 	// Fetch YouTube
 	go func() {
 		defer wg.Done()
-		data.Comments, youtubeErr = FetchYouTubeComments(partyName)
+		var comments []YouTubeComment
+		res := runSource(fetchCtx, SourceYouTube, sourceTimeout(SourceYouTube), func(ctx context.Context) (int, error) {
+			if Breaker(SourceYouTube).IsOpen() {
+				return 0, fmt.Errorf("%w: %s circuit open", errSkipped, SourceYouTube)
+			}
+			if !CanAffordYouTubeRun() {
+				return 0, fmt.Errorf("%w: %d quota units left today, run needs ~%d",
+					errSkipped, YouTubeQuota.Remaining(), EstimateYouTubeRunCost())
+			}
+			var err error
+			comments, err = FetchYouTubeComments(ctx, partyName)
+			return len(comments), err
+		})
+		finish(2, res, func() { data.Comments = comments })
 	}()
 
 	// Fetch Reddit
 	go func() {
 		defer wg.Done()
-		data.RedditPosts, redditErr = FetchRedditPosts(partyName)
+		var posts []RedditPost
+		res := runSource(fetchCtx, SourceReddit, sourceTimeout(SourceReddit), func(ctx context.Context) (int, error) {
+			if Breaker(SourceReddit).IsOpen() {
+				return 0, fmt.Errorf("%w: %s circuit open", errSkipped, SourceReddit)
+			}
+			var err error
+			posts, err = FetchRedditPosts(ctx, partyName)
+			return redditItemCount(posts), err
+		})
+		finish(3, res, func() { data.RedditPosts = posts })
 	}()
 
-	wg.Wait()
-
-	if newsErr != nil {
-		fmt.Printf("News fetch error: %v\n", newsErr)
+	// Sources return promptly once fetchCtx ends; the grace period only
+	// guards against one that doesn't
+	allDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(allDone)
+	}()
+	select {
+	case <-allDone:
+	case <-fetchCtx.Done():
+		select {
+		case <-allDone:
+		case <-time.After(2 * time.Second):
+		}
 	}
-	if youtubeErr != nil {
-		fmt.Printf("YouTube fetch error: %v\n", youtubeErr)
+
+	mu.Lock()
+	done = true
+	for i, res := range results {
+		if res.Source == "" {
+			results[i] = SourceResult{Source: names[i], Status: SourceFailed, Error: "no result before the fetch deadline"}
+		}
 	}
-	if redditErr != nil {
-		fmt.Printf("Reddit fetch error: %v\n", redditErr)
+	data.Sources = results
+	mu.Unlock()
+
+	data.News = filterRecentNews(data.News, envDuration("NEWS_MAX_AGE", 72*time.Hour))
+
+	// Headlines alone are mostly clickbait; pull the article text too
+	EnrichArticles(ctx, data.News)
+
+	// Flag brigading before anything gets scored
+	data.Inauthentic = DetectInauthentic(&data)
+	if data.Inauthentic.FlaggedItems > 0 {
+		fmt.Printf("Flagged %d/%d social items as suspected inorganic (%d duplicate clusters)\n",
+			data.Inauthentic.FlaggedItems, data.Inauthentic.TotalItems, data.Inauthentic.Clusters)
 	}
-	if newsDataErr != nil {
-		fmt.Printf("NewsData fetch error: %v\n", newsDataErr)
+
+	data.Coverage = EvaluateCoverage(&data)
+	if data.Coverage.Degraded {
+		fmt.Printf("Run is degraded: %v\n", data.Coverage.Reasons)
 	}
 
 	return &data, nil
 }
+
+// redditItemCount counts posts plus their comments.
+func redditItemCount(posts []RedditPost) int {
+	n := len(posts)
+	for _, p := range posts {
+		n += len(p.Comments)
+	}
+	return n
+}
+
+// Coverage summarises how much evidence a run had. A degraded run still gets
+// a score, but callers should not read much into it.
+type Coverage struct {
+	NewsItems   int      `json:"news_items"`
+	SocialItems int      `json:"social_items"` // YouTube comments, Reddit posts and comments
+	SourcesOK   int      `json:"sources_ok"`
+	Degraded    bool     `json:"degraded"`
+	Reasons     []string `json:"degraded_reasons,omitempty"`
+}
+
+// EvaluateCoverage checks a run against the COVERAGE_MIN_* thresholds.
+func EvaluateCoverage(data *AggregatedData) Coverage {
+	cov := Coverage{
+		NewsItems:   len(data.News),
+		SocialItems: len(data.Comments) + redditItemCount(data.RedditPosts),
+	}
+	for _, s := range data.Sources {
+		if s.Status == SourceOK {
+			cov.SourcesOK++
+		}
+	}
+
+	if floor := envInt("COVERAGE_MIN_NEWS", 5); cov.NewsItems < floor {
+		cov.Reasons = append(cov.Reasons, fmt.Sprintf("only %d news items (minimum %d)", cov.NewsItems, floor))
+	}
+	if floor := envInt("COVERAGE_MIN_SOCIAL", 10); cov.SocialItems < floor {
+		cov.Reasons = append(cov.Reasons, fmt.Sprintf("only %d social items (minimum %d)", cov.SocialItems, floor))
+	}
+	if floor := envInt("COVERAGE_MIN_SOURCES", 3); len(data.Sources) > 0 && cov.SourcesOK < floor {
+		cov.Reasons = append(cov.Reasons, fmt.Sprintf("only %d of %d sources succeeded (minimum %d)", cov.SourcesOK, len(data.Sources), floor))
+	}
+	cov.Degraded = len(cov.Reasons) > 0
+	return cov
+}
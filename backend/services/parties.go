@@ -0,0 +1,109 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrPartyNotFound = errors.New("party not found")
+
+// ResolveParty finds a party by ID, name or alias. Names and aliases match
+// case-insensitively, so "dmk", "DMK" and "Dravida Munnetra Kazhagam" all
+// resolve to the same row.
+func ResolveParty(ref string) (models.Party, error) {
+	var party models.Party
+	ref = strings.TrimSpace(ref)
+	if ref == "" || db.DB == nil {
+		return party, ErrPartyNotFound
+	}
+
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		if err := db.DB.First(&party, id).Error; err != nil {
+			return party, ErrPartyNotFound
+		}
+		return party, nil
+	}
+
+	if err := db.DB.Where("LOWER(name) = LOWER(?)", ref).First(&party).Error; err == nil {
+		return party, nil
+	}
+
+	// Only a handful of parties, so matching aliases in Go is fine
+	var parties []models.Party
+	if err := db.DB.Find(&parties).Error; err != nil {
+		return party, err
+	}
+	for _, p := range parties {
+		for _, alias := range p.Aliases {
+			if strings.EqualFold(alias, ref) {
+				return p, nil
+			}
+		}
+	}
+	return party, ErrPartyNotFound
+}
+
+// partyNames lists the names documents may use for the party query refers
+// to: its name and aliases, or just query when it isn't a known party.
+func partyNames(query string) []string {
+	party, err := ResolveParty(query)
+	if err != nil {
+		return []string{query}
+	}
+	names := []string{party.Name}
+	for _, a := range party.Aliases {
+		if a = strings.TrimSpace(a); a != "" {
+			names = append(names, a)
+		}
+	}
+	return names
+}
+
+// namesPattern matches any of names as a whole word, so "DMK" doesn't match
+// inside "AIADMK". Word edges are spelled out as in leaderPattern.
+func namesPattern(names []string) *regexp.Regexp {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = regexp.QuoteMeta(n)
+	}
+	const edge = `[^\p{L}\p{M}\p{N}]`
+	return regexp.MustCompile(`(?i)(?:^|` + edge + `)(?:` + strings.Join(quoted, "|") + `)(?:$|` + edge + `)`)
+}
+
+// SetPartyAlliance moves a party into the named alliance from since on,
+// closing its current membership. An empty name just leaves the current
+// alliance. The alliance is created if it doesn't exist yet.
+func SetPartyAlliance(tx *gorm.DB, partyID uint, allianceName string, since time.Time) error {
+	var current models.AllianceMembership
+	err := tx.Preload("Alliance").Where("party_id = ? AND until IS NULL", partyID).First(&current).Error
+	hasCurrent := err == nil
+	if hasCurrent && current.Alliance != nil && strings.EqualFold(current.Alliance.Name, allianceName) {
+		return nil // Already there
+	}
+
+	if hasCurrent {
+		if err := tx.Model(&current).Update("until", since).Error; err != nil {
+			return err
+		}
+	}
+	if allianceName == "" {
+		return nil
+	}
+
+	var alliance models.Alliance
+	if err := tx.Where("LOWER(name) = LOWER(?)", allianceName).First(&alliance).Error; err != nil {
+		alliance = models.Alliance{Name: allianceName}
+		if err := tx.Create(&alliance).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(&models.AllianceMembership{AllianceID: alliance.ID, PartyID: partyID, Since: since}).Error
+}
@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestNamesPattern(t *testing.T) {
+	dmk := namesPattern([]string{"DMK", "Dravida Munnetra Kazhagam", "திமுக"})
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"DMK announces candidates", true},
+		{"Why the dmk won Chennai", true},
+		{"AIADMK announces candidates", false},
+		{"DMKs and more", false},
+		{"Dravida Munnetra Kazhagam turns 75", true},
+		{"திமுக கூட்டணி", true},
+		{"அதிமுக கூட்டணி", false},
+		{"(DMK)", true},
+	}
+	for _, tt := range tests {
+		if got := dmk.MatchString(tt.text); got != tt.want {
+			t.Errorf("MatchString(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// PollInput is one poll as accepted by ImportPolls.
+type PollInput struct {
+	Pollster   string             `json:"pollster"`
+	Date       string             `json:"date"` // YYYY-MM-DD
+	SampleSize int                `json:"sample_size"`
+	SourceURL  string             `json:"source_url"`
+	Shares     map[string]float64 `json:"shares"` // Party name -> percent
+}
+
+type PollImportSummary struct {
+	Polls     int      `json:"polls"`
+	Unmatched []string `json:"unmatched_parties"` // Stored, but not used for calibration
+}
+
+// pollMetaColumns are the CSV columns that aren't party shares.
+var pollMetaColumns = map[string]string{
+	"pollster": "pollster", "agency": "pollster",
+	"date": "date", "field_end": "date", "published": "date",
+	"sample_size": "sample_size", "sample": "sample_size", "n": "sample_size",
+	"source_url": "source_url", "source": "source_url", "url": "source_url",
+}
+
+// ParsePollsJSON reads a JSON array of polls.
+func ParsePollsJSON(r io.Reader) ([]PollInput, error) {
+	var polls []PollInput
+	if err := json.NewDecoder(r).Decode(&polls); err != nil {
+		return nil, fmt.Errorf("invalid polls JSON: %w", err)
+	}
+	return polls, nil
+}
+
+// ParsePollsCSV reads polls in the wide layout pollsters publish: one row per
+// poll, pollster/date/sample_size columns, then one column per party.
+func ParsePollsCSV(r io.Reader) ([]PollInput, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	meta := make(map[string]int)
+	partyCols := make(map[int]string)
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if field, ok := pollMetaColumns[strings.ToLower(h)]; ok {
+			meta[field] = i
+		} else if h != "" {
+			partyCols[i] = h
+		}
+	}
+	if _, ok := meta["pollster"]; !ok {
+		return nil, errors.New("missing pollster column")
+	}
+	if _, ok := meta["date"]; !ok {
+		return nil, errors.New("missing date column")
+	}
+	if len(partyCols) == 0 {
+		return nil, errors.New("no party columns")
+	}
+
+	var polls []PollInput
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		get := func(field string) string {
+			if i, ok := meta[field]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		p := PollInput{Pollster: get("pollster"), Date: get("date"), SourceURL: get("source_url"), Shares: make(map[string]float64)}
+		if n := get("sample_size"); n != "" {
+			if p.SampleSize, err = strconv.Atoi(strings.ReplaceAll(n, ",", "")); err != nil {
+				return nil, fmt.Errorf("line %d: invalid sample size %q", line, n)
+			}
+		}
+		for i, party := range partyCols {
+			if i >= len(rec) || strings.TrimSpace(rec[i]) == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(rec[i]), "%"), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid share for %s: %q", line, party, rec[i])
+			}
+			p.Shares[party] = v
+		}
+		polls = append(polls, p)
+	}
+	return polls, nil
+}
+
+// ImportPolls validates and stores polls. A poll with the same pollster and
+// date as a stored one replaces it.
+func ImportPolls(polls []PollInput) (*PollImportSummary, error) {
+	if len(polls) == 0 {
+		return nil, errors.New("no polls in input")
+	}
+
+	partyIDs := make(map[string]*uint)
+	unmatched := make(map[string]bool)
+	rows := make([]models.Poll, 0, len(polls))
+	for i, in := range polls {
+		in.Pollster = strings.TrimSpace(in.Pollster)
+		if in.Pollster == "" {
+			return nil, fmt.Errorf("poll %d: pollster is required", i+1)
+		}
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(in.Date))
+		if err != nil {
+			return nil, fmt.Errorf("poll %d (%s): date must be YYYY-MM-DD", i+1, in.Pollster)
+		}
+		if in.SampleSize < 0 {
+			return nil, fmt.Errorf("poll %d (%s): sample_size can't be negative", i+1, in.Pollster)
+		}
+		if len(in.Shares) == 0 {
+			return nil, fmt.Errorf("poll %d (%s): no vote shares", i+1, in.Pollster)
+		}
+
+		poll := models.Poll{Pollster: in.Pollster, Date: date, SampleSize: in.SampleSize, SourceURL: in.SourceURL}
+		total := 0.0
+		for name, share := range in.Shares {
+			name = strings.TrimSpace(name)
+			if share < 0 || share > 100 {
+				return nil, fmt.Errorf("poll %d (%s): share for %s must be a percentage", i+1, in.Pollster, name)
+			}
+			total += share
+			id, seen := partyIDs[strings.ToLower(name)]
+			if !seen {
+				if p, err := ResolveParty(name); err == nil {
+					pid := p.ID
+					id = &pid
+				}
+				partyIDs[strings.ToLower(name)] = id
+			}
+			if id == nil {
+				unmatched[name] = true
+			}
+			poll.Shares = append(poll.Shares, models.PollShare{PartyName: name, PartyID: id, Share: share})
+		}
+		if total > 101 {
+			return nil, fmt.Errorf("poll %d (%s): shares add up to %.1f%%", i+1, in.Pollster, total)
+		}
+		sort.Slice(poll.Shares, func(a, b int) bool { return poll.Shares[a].Share > poll.Shares[b].Share })
+		rows = append(rows, poll)
+	}
+
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range rows {
+			var prev models.Poll
+			if tx.Where("pollster = ? AND date = ?", rows[i].Pollster, rows[i].Date).First(&prev).Error == nil {
+				if err := tx.Where("poll_id = ?", prev.ID).Delete(&models.PollShare{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Delete(&prev).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&rows[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	InvalidateCalibration()
+
+	summary := &PollImportSummary{Polls: len(rows), Unmatched: []string{}}
+	for name := range unmatched {
+		summary.Unmatched = append(summary.Unmatched, name)
+	}
+	sort.Strings(summary.Unmatched)
+	return summary, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePollsCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []PollInput
+		wantErr string
+	}{
+		{
+			name: "wide layout",
+			csv: "Pollster,Date,Sample,DMK,AIADMK,TVK\n" +
+				"CVoter,2026-03-01,\"5,000\",38.5%,30,\n" +
+				"Lokniti,2026-02-20,,40,28.5,12\n",
+			want: []PollInput{
+				{Pollster: "CVoter", Date: "2026-03-01", SampleSize: 5000, Shares: map[string]float64{"DMK": 38.5, "AIADMK": 30}},
+				{Pollster: "Lokniti", Date: "2026-02-20", Shares: map[string]float64{"DMK": 40, "AIADMK": 28.5, "TVK": 12}},
+			},
+		},
+		{
+			name: "aliases and source",
+			csv:  "\ufeffagency,field_end,n,url,DMK\nCVoter,2026-03-01,1200,https://example.com/poll,38\n",
+			want: []PollInput{
+				{Pollster: "CVoter", Date: "2026-03-01", SampleSize: 1200, SourceURL: "https://example.com/poll", Shares: map[string]float64{"DMK": 38}},
+			},
+		},
+		{
+			name:    "no pollster",
+			csv:     "date,DMK\n2026-03-01,38\n",
+			wantErr: "missing pollster column",
+		},
+		{
+			name:    "no date",
+			csv:     "pollster,DMK\nCVoter,38\n",
+			wantErr: "missing date column",
+		},
+		{
+			name:    "no parties",
+			csv:     "pollster,date\nCVoter,2026-03-01\n",
+			wantErr: "no party columns",
+		},
+		{
+			name:    "bad share",
+			csv:     "pollster,date,DMK\nCVoter,2026-03-01,lots\n",
+			wantErr: "line 2: invalid share for DMK",
+		},
+		{
+			name:    "bad sample size",
+			csv:     "pollster,date,sample_size,DMK\nCVoter,2026-03-01,big,38\n",
+			wantErr: "line 2: invalid sample size",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParsePollsCSV(strings.NewReader(tt.csv))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
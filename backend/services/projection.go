@@ -0,0 +1,498 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Swing methods
+const (
+	SwingUniform      = "uniform"      // Every seat moves by the statewide swing
+	SwingProportional = "proportional" // Seats move in proportion to the party's local strength
+)
+
+var ErrNoResults = errors.New("no election results imported")
+
+// ProjectionOptions are the knobs of one projection request.
+type ProjectionOptions struct {
+	BaseYear     int    // Election to swing from; 0 means the latest imported
+	Method       string // SwingUniform or SwingProportional
+	Runs         int    // Monte Carlo runs
+	Seed         uint64 // 0 picks a random seed
+	RecentDays   int    // Window for the current sentiment
+	BaselineDays int    // Window before it that sentiment is compared with
+	Seats        bool   // Include per-constituency outlooks
+
+	AsOf time.Time // Project with only what was known then; zero means now
+}
+
+// PartySwing is how sentiment moved for a party and the vote swing it implies.
+type PartySwing struct {
+	PartyID   uint     `json:"party_id"`
+	Name      string   `json:"name"`
+	Recent    *float64 `json:"recent_score"`   // Mean score over the recent window
+	Baseline  *float64 `json:"baseline_score"` // Mean score over the window before
+	Delta     float64  `json:"sentiment_delta"`
+	Swing     float64  `json:"swing"`      // Vote share points, statewide
+	BaseShare float64  `json:"base_share"` // Statewide vote share at the base election
+}
+
+// SeatProb is one bar of a seat distribution.
+type SeatProb struct {
+	Seats int     `json:"seats"`
+	Prob  float64 `json:"prob"`
+}
+
+// SeatProjection is the seat outlook of a party or alliance.
+type SeatProjection struct {
+	ID           uint       `json:"id"` // Party or alliance ID; 0 for Others
+	Name         string     `json:"name"`
+	Point        int        `json:"point"` // Seats with no noise, just the swing
+	Mean         float64    `json:"mean"`
+	Median       int        `json:"median"`
+	Low          int        `json:"low"`  // 5th percentile
+	High         int        `json:"high"` // 95th percentile
+	ProbMajority float64    `json:"prob_majority"`
+	Distribution []SeatProb `json:"distribution"`
+}
+
+// SeatOutlook is one constituency's projected result.
+type SeatOutlook struct {
+	Number   int                `json:"number"`
+	Name     string             `json:"name"`
+	Region   string             `json:"region,omitempty"`
+	Previous string             `json:"previous_winner"`
+	Point    string             `json:"projected_winner"`
+	WinProbs map[string]float64 `json:"win_probs"` // By party name, parties that won at least one run
+}
+
+type Projection struct {
+	BaseYear    int              `json:"base_year"`
+	Method      string           `json:"method"`
+	Runs        int              `json:"runs"`
+	Seed        uint64           `json:"seed"`
+	TotalSeats  int              `json:"total_seats"`
+	Majority    int              `json:"majority"`
+	SwingPerPt  float64          `json:"swing_per_point"` // Vote share points per sentiment point
+	SwingSource string           `json:"swing_source"`    // "config", "calibration" or "default"
+	Swings      []PartySwing     `json:"swings"`
+	Parties     []SeatProjection `json:"parties"`
+	Alliances   []SeatProjection `json:"alliances"`
+	Seats       []SeatOutlook    `json:"constituencies,omitempty"`
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// contender is a tracked party, or index 0 for everyone else.
+type contender struct {
+	partyID uint
+	name    string
+	bloc    int // Index into blocs
+}
+
+type bloc struct {
+	allianceID uint // 0 for a party outside any alliance, or Others
+	name       string
+}
+
+type seatBase struct {
+	c      models.Constituency
+	shares []float64 // Per contender, percent
+	prev   string
+}
+
+// swingPerPoint converts a sentiment change into vote share points. An
+// explicit SWING_PER_POINT wins, then the poll calibration's slope if it has
+// a sensible (positive) one, then a default. A non-zero asOf calibrates on
+// polls from before then only.
+func swingPerPoint(asOf time.Time) (float64, string) {
+	if os.Getenv("SWING_PER_POINT") != "" {
+		return envFloat("SWING_PER_POINT", 0.2), "config"
+	}
+	cal, err := CurrentCalibration()
+	if !asOf.IsZero() {
+		cal, err = FitCalibrationAsOf(asOf)
+	}
+	if err == nil && cal.Slope > 0 {
+		return cal.Slope, "calibration"
+	}
+	return 0.2, "default"
+}
+
+// meanScore is the mean snapshot score of a party over [from, to), or nil.
+func meanScore(partyID uint, from, to time.Time) *float64 {
+	var row struct {
+		Avg *float64
+		N   int
+	}
+	db.DB.Model(&models.SentimentSnapshot{}).Select("AVG(score) AS avg, COUNT(*) AS n").
+		Where("party_id = ? AND created_at >= ? AND created_at < ?", partyID, from.UTC(), to.UTC()).Scan(&row)
+	if row.N == 0 {
+		return nil
+	}
+	return row.Avg
+}
+
+// projectionCacheSize bounds the cache, since seeds make keys cheap to vary.
+const projectionCacheSize = 100
+
+type cachedProjection struct {
+	proj *Projection
+	at   time.Time
+}
+
+var (
+	projectionFlights singleflight.Group
+	projectionMu      sync.Mutex
+	projectionCache   = make(map[string]cachedProjection)
+)
+
+// ProjectCached is Project for the public endpoint: identical requests within
+// PROJECTION_CACHE_TTL (default 10m) get the same result, and concurrent ones
+// share a single run. Without a seed that means repeated requests see the
+// same draw until it expires.
+func ProjectCached(opts ProjectionOptions) (*Projection, error) {
+	key := fmt.Sprintf("%d:%s:%d:%d:%d:%d:%t:%s", opts.BaseYear, opts.Method, opts.Runs, opts.Seed,
+		opts.RecentDays, opts.BaselineDays, opts.Seats, opts.AsOf.UTC().Format(time.RFC3339))
+	ttl := envDuration("PROJECTION_CACHE_TTL", 10*time.Minute)
+
+	projectionMu.Lock()
+	cached, ok := projectionCache[key]
+	projectionMu.Unlock()
+	if ok && time.Since(cached.at) < ttl {
+		return cached.proj, nil
+	}
+
+	v, err, _ := projectionFlights.Do(key, func() (interface{}, error) {
+		proj, err := Project(opts)
+		if err != nil {
+			return nil, err
+		}
+		projectionMu.Lock()
+		defer projectionMu.Unlock()
+		for k, c := range projectionCache {
+			if time.Since(c.at) >= ttl {
+				delete(projectionCache, k)
+			}
+		}
+		if len(projectionCache) < projectionCacheSize {
+			projectionCache[key] = cachedProjection{proj: proj, at: time.Now()}
+		}
+		return proj, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Projection), nil
+}
+
+// Project turns sentiment movement into seat ranges. Base results are taken
+// from opts.BaseYear, party votes are grouped by the alliances of the day, each
+// party's share moves by its sentiment swing, and Monte Carlo runs add
+// statewide and per-seat noise to get distributions.
+func Project(opts ProjectionOptions) (*Projection, error) {
+	if opts.BaseYear == 0 {
+		opts.BaseYear = LatestResultYear()
+	}
+	if opts.BaseYear == 0 {
+		return nil, ErrNoResults
+	}
+	if opts.Seed == 0 {
+		opts.Seed = rand.Uint64()
+	}
+
+	var results []models.ElectionResult
+	if err := db.DB.Where("year = ?", opts.BaseYear).Find(&results).Error; err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w for %d", ErrNoResults, opts.BaseYear)
+	}
+	var constituencies []models.Constituency
+	if err := db.DB.Order("number").Find(&constituencies).Error; err != nil {
+		return nil, err
+	}
+
+	var parties []models.Party
+	if err := db.DB.Where("active = ?", true).Order("id").Find(&parties).Error; err != nil {
+		return nil, err
+	}
+
+	// Blocs: the day's alliances, plus each unaligned party on its own
+	now := time.Now().UTC()
+	if !opts.AsOf.IsZero() {
+		now = opts.AsOf.UTC()
+	}
+	var memberships []models.AllianceMembership
+	db.DB.Preload("Alliance").Where("since <= ? AND (until IS NULL OR until > ?)", now, now).Find(&memberships)
+	allianceOf := make(map[uint]*models.Alliance)
+	for _, m := range memberships {
+		if m.Alliance != nil {
+			allianceOf[m.PartyID] = m.Alliance
+		}
+	}
+
+	contenders := []contender{{name: "Others"}}
+	blocs := []bloc{{name: "Others"}}
+	blocIndex := map[uint]int{} // Alliance ID -> bloc
+	index := map[uint]int{}     // Party ID -> contender
+	for _, p := range parties {
+		b := -1
+		if a := allianceOf[p.ID]; a != nil {
+			if i, ok := blocIndex[a.ID]; ok {
+				b = i
+			} else {
+				b = len(blocs)
+				blocIndex[a.ID] = b
+				blocs = append(blocs, bloc{allianceID: a.ID, name: a.Name})
+			}
+		} else {
+			b = len(blocs)
+			blocs = append(blocs, bloc{name: p.Name})
+		}
+		index[p.ID] = len(contenders)
+		contenders = append(contenders, contender{partyID: p.ID, name: p.Name, bloc: b})
+	}
+
+	// Base shares per seat and statewide
+	bySeat := make(map[uint]*seatBase)
+	for _, c := range constituencies {
+		bySeat[c.ID] = &seatBase{c: c, shares: make([]float64, len(contenders))}
+	}
+	stateVotes := make([]float64, len(contenders))
+	totalVotes := 0.0
+	for _, r := range results {
+		s := bySeat[r.ConstituencyID]
+		if s == nil {
+			continue
+		}
+		i := 0
+		if r.PartyID != nil {
+			if j, ok := index[*r.PartyID]; ok {
+				i = j
+			}
+		}
+		if i == 0 {
+			// Untracked parties don't pool their votes, so Others runs as its
+			// strongest single candidate
+			s.shares[0] = math.Max(s.shares[0], r.VoteShare)
+		} else {
+			s.shares[i] += r.VoteShare
+		}
+		stateVotes[i] += float64(r.Votes)
+		totalVotes += float64(r.Votes)
+		if r.Won {
+			s.prev = r.PartyName
+		}
+	}
+	var seats []*seatBase
+	for _, c := range constituencies {
+		if s := bySeat[c.ID]; s.prev != "" {
+			seats = append(seats, s)
+		}
+	}
+
+	// Sentiment swing per party
+	coef, coefSource := swingPerPoint(opts.AsOf)
+	recentFrom := now.AddDate(0, 0, -opts.RecentDays)
+	baseFrom := recentFrom.AddDate(0, 0, -opts.BaselineDays)
+	swings := make([]float64, len(contenders))
+	stateShare := make([]float64, len(contenders))
+	proj := &Projection{
+		BaseYear:    opts.BaseYear,
+		Method:      opts.Method,
+		Runs:        opts.Runs,
+		Seed:        opts.Seed,
+		TotalSeats:  len(seats),
+		Majority:    len(seats)/2 + 1,
+		SwingPerPt:  coef,
+		SwingSource: coefSource,
+		GeneratedAt: now,
+	}
+	for i, c := range contenders {
+		if totalVotes > 0 {
+			stateShare[i] = 100 * stateVotes[i] / totalVotes
+		}
+		if i == 0 {
+			continue
+		}
+		ps := PartySwing{PartyID: c.partyID, Name: c.name, BaseShare: stateShare[i]}
+		ps.Recent = meanScore(c.partyID, recentFrom, now)
+		ps.Baseline = meanScore(c.partyID, baseFrom, recentFrom)
+		if ps.Recent != nil && ps.Baseline != nil {
+			ps.Delta = *ps.Recent - *ps.Baseline
+			ps.Swing = coef * ps.Delta
+		}
+		swings[i] = ps.Swing
+		proj.Swings = append(proj.Swings, ps)
+	}
+
+	// localShare is a contender's share in a seat after swing and noise
+	// A party that skipped the seat last time (usually leaving it to an ally)
+	// stays out; only parties new since the base election start from zero.
+	localShare := func(s *seatBase, i int, stateErr float64) float64 {
+		base := s.shares[i]
+		if base == 0 && stateShare[i] > 0 {
+			return 0
+		}
+		swing := swings[i] + stateErr
+		if opts.Method == SwingProportional && stateShare[i] > 0 {
+			swing *= base / stateShare[i]
+		}
+		return math.Max(0, base+swing)
+	}
+
+	// winner returns the contender that takes the seat: the strongest bloc,
+	// represented by the member that polled most there last time, as the
+	// likely candidate. rng nil gives the noiseless point projection.
+	seatSD := envFloat("SWING_SD_SEAT", 3)
+	blocShares := make([]float64, len(blocs))
+	candidate := make([]int, len(blocs))
+	winner := func(s *seatBase, stateErr []float64, rng *rand.Rand) int {
+		for b := range blocs {
+			blocShares[b] = 0
+			candidate[b] = -1
+		}
+		for i, c := range contenders {
+			blocShares[c.bloc] += localShare(s, i, stateErr[i])
+			if candidate[c.bloc] == -1 || s.shares[i] > s.shares[candidate[c.bloc]] {
+				candidate[c.bloc] = i
+			}
+		}
+		best, bestShare := 0, math.Inf(-1)
+		for b := range blocs {
+			if candidate[b] == -1 {
+				continue
+			}
+			v := blocShares[b]
+			if rng != nil {
+				v += rng.NormFloat64() * seatSD
+			}
+			if v > bestShare {
+				best, bestShare = b, v
+			}
+		}
+		return candidate[best]
+	}
+
+	// Point projection
+	noErr := make([]float64, len(contenders))
+	pointParty := make([]int, len(contenders))
+	pointSeat := make([]int, len(seats))
+	for k, s := range seats {
+		w := winner(s, noErr, nil)
+		pointParty[w]++
+		pointSeat[k] = w
+	}
+
+	// Monte Carlo
+	rng := rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15))
+	stateSD := envFloat("SWING_SD_STATE", 2)
+	partyTally := make([][]int, len(contenders)) // [contender][run]
+	for i := range partyTally {
+		partyTally[i] = make([]int, opts.Runs)
+	}
+	seatWins := make([][]int, len(seats)) // [seat][contender]
+	for k := range seatWins {
+		seatWins[k] = make([]int, len(contenders))
+	}
+	stateErr := make([]float64, len(contenders))
+	for run := 0; run < opts.Runs; run++ {
+		for i := range stateErr {
+			stateErr[i] = rng.NormFloat64() * stateSD
+		}
+		for k, s := range seats {
+			w := winner(s, stateErr, rng)
+			partyTally[w][run]++
+			seatWins[k][w]++
+		}
+	}
+
+	for i, c := range contenders {
+		sp := summarise(partyTally[i], proj.Majority)
+		sp.ID, sp.Name, sp.Point = c.partyID, c.name, pointParty[i]
+		proj.Parties = append(proj.Parties, sp)
+	}
+	for b, bl := range blocs {
+		tally := make([]int, opts.Runs)
+		point := 0
+		for i, c := range contenders {
+			if c.bloc != b {
+				continue
+			}
+			point += pointParty[i]
+			for run, n := range partyTally[i] {
+				tally[run] += n
+			}
+		}
+		sp := summarise(tally, proj.Majority)
+		sp.ID, sp.Name, sp.Point = bl.allianceID, bl.name, point
+		proj.Alliances = append(proj.Alliances, sp)
+	}
+	byMean := func(list []SeatProjection) {
+		sort.SliceStable(list, func(a, b int) bool { return list[a].Mean > list[b].Mean })
+	}
+	byMean(proj.Parties)
+	byMean(proj.Alliances)
+
+	if opts.Seats {
+		for k, s := range seats {
+			o := SeatOutlook{
+				Number:   s.c.Number,
+				Name:     s.c.Name,
+				Region:   s.c.Region,
+				Previous: s.prev,
+				Point:    contenders[pointSeat[k]].name,
+				WinProbs: make(map[string]float64),
+			}
+			for i, n := range seatWins[k] {
+				if n > 0 {
+					o.WinProbs[contenders[i].name] = float64(n) / float64(opts.Runs)
+				}
+			}
+			proj.Seats = append(proj.Seats, o)
+		}
+	}
+	return proj, nil
+}
+
+// summarise turns per-run seat counts into a distribution and its summary.
+func summarise(tally []int, majority int) SeatProjection {
+	var sp SeatProjection
+	if len(tally) == 0 {
+		return sp
+	}
+	sorted := append([]int(nil), tally...)
+	sort.Ints(sorted)
+	pct := func(p float64) int { return sorted[int(p*float64(len(sorted)-1))] }
+
+	counts := make(map[int]int)
+	sum, wins := 0, 0
+	for _, n := range tally {
+		counts[n]++
+		sum += n
+		if n >= majority {
+			wins++
+		}
+	}
+	sp.Mean = float64(sum) / float64(len(tally))
+	sp.Median = pct(0.5)
+	sp.Low = pct(0.05)
+	sp.High = pct(0.95)
+	sp.ProbMajority = float64(wins) / float64(len(tally))
+	for n, c := range counts {
+		sp.Distribution = append(sp.Distribution, SeatProb{Seats: n, Prob: float64(c) / float64(len(tally))})
+	}
+	sort.Slice(sp.Distribution, func(a, b int) bool { return sp.Distribution[a].Seats < sp.Distribution[b].Seats })
+	return sp
+}
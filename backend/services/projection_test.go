@@ -0,0 +1,56 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarise(t *testing.T) {
+	tests := []struct {
+		name     string
+		tally    []int
+		majority int
+		want     SeatProjection
+	}{
+		{
+			name:  "no runs",
+			tally: nil,
+			want:  SeatProjection{},
+		},
+		{
+			name:     "one outcome",
+			tally:    []int{120, 120, 120, 120},
+			majority: 118,
+			want: SeatProjection{
+				Mean: 120, Median: 120, Low: 120, High: 120, ProbMajority: 1,
+				Distribution: []SeatProb{{Seats: 120, Prob: 1}},
+			},
+		},
+		{
+			name:     "spread",
+			tally:    []int{130, 100, 110, 120, 140},
+			majority: 118,
+			want: SeatProjection{
+				Mean: 120, Median: 120, Low: 100, High: 130, ProbMajority: 0.6,
+				Distribution: []SeatProb{
+					{Seats: 100, Prob: 0.2}, {Seats: 110, Prob: 0.2}, {Seats: 120, Prob: 0.2},
+					{Seats: 130, Prob: 0.2}, {Seats: 140, Prob: 0.2},
+				},
+			},
+		},
+		{
+			name:     "short of majority",
+			tally:    []int{10, 12, 10, 12},
+			majority: 118,
+			want: SeatProjection{
+				Mean: 11, Median: 10, Low: 10, High: 12,
+				Distribution: []SeatProb{{Seats: 10, Prob: 0.5}, {Seats: 12, Prob: 0.5}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := summarise(tt.tally, tt.majority); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	_ "time/tzdata" // The alpine runtime image ships without zoneinfo
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaLedger tracks daily API quota for one provider. Usage is counted in
+// memory and synced with quota_usages every QUOTA_SYNC_INTERVAL, so restarts
+// and multiple replicas share the same count without a database round trip
+// per call. Without a database the in-memory count is all there is.
+type QuotaLedger struct {
+	Service    string
+	DailyLimit int            // Default limit
+	LimitEnv   string         // Env var that overrides DailyLimit, read on use so .env applies
+	Location   *time.Location // Timezone whose midnight resets the quota
+
+	mu        sync.Mutex
+	day       string
+	used      map[string]int        // Operation -> units today: the stored total as of syncedAt plus pending
+	pending   map[string]quotaDelta // Spent here but not written to quota_usages yet
+	syncedAt  time.Time
+	exhausted string // Day the provider told us quota ran out
+}
+
+type quotaDelta struct {
+	units, calls int
+}
+
+type QuotaStatus struct {
+	Service    string         `json:"service"`
+	Day        string         `json:"day"`
+	Limit      int            `json:"limit"`
+	Used       int            `json:"used"`
+	Remaining  int            `json:"remaining"`
+	ResetsAt   time.Time      `json:"resets_at"`
+	Operations map[string]int `json:"operations"` // Units per API operation
+}
+
+func pacificTime() *time.Location {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return time.FixedZone("PST", -8*60*60)
+	}
+	return loc
+}
+
+// YouTubeQuota is shared by every YouTube Data API call. The project default
+// is 10,000 units per Pacific day.
+var YouTubeQuota = &QuotaLedger{
+	Service:    "youtube",
+	DailyLimit: 10000,
+	LimitEnv:   "YOUTUBE_DAILY_QUOTA",
+	Location:   pacificTime(),
+}
+
+// Limit is today's quota, from LimitEnv if it is set.
+func (q *QuotaLedger) Limit() int {
+	if q.LimitEnv == "" {
+		return q.DailyLimit
+	}
+	return envInt(q.LimitEnv, q.DailyLimit)
+}
+
+func (q *QuotaLedger) today() string {
+	return time.Now().In(q.Location).Format("2006-01-02")
+}
+
+// Spend records units against an operation for the current quota day.
+// Replayed calls never reach the provider, so they aren't recorded.
+func (q *QuotaLedger) Spend(operation string, units int) {
+	if httpMode() == HTTPReplay {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollDay(q.today())
+
+	q.used[operation] += units
+	if db.DB == nil {
+		return
+	}
+	d := q.pending[operation]
+	d.units += units
+	d.calls++
+	q.pending[operation] = d
+	q.syncIfDue()
+}
+
+// Flush writes pending usage now, e.g. at the end of a run.
+func (q *QuotaLedger) Flush() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if db.DB != nil && len(q.pending) > 0 {
+		q.sync()
+	}
+}
+
+// rollDay starts a fresh count when the quota day changes, writing out
+// what is still pending for the old day first. Callers hold q.mu.
+func (q *QuotaLedger) rollDay(day string) {
+	if q.day == day {
+		return
+	}
+	if db.DB != nil && len(q.pending) > 0 {
+		q.flush()
+	}
+	q.day, q.used, q.pending, q.syncedAt = day, make(map[string]int), make(map[string]quotaDelta), time.Time{}
+}
+
+func (q *QuotaLedger) syncIfDue() {
+	if time.Since(q.syncedAt) >= envDuration("QUOTA_SYNC_INTERVAL", 30*time.Second) {
+		q.sync()
+	}
+}
+
+// sync writes pending usage and reloads today's totals, which picks up what
+// other replicas spent. Callers hold q.mu.
+func (q *QuotaLedger) sync() {
+	q.flush()
+
+	var rows []models.QuotaUsage
+	if err := db.DB.Where("service = ? AND day = ?", q.Service, q.day).Find(&rows).Error; err != nil {
+		fmt.Printf("Failed to read %s quota usage: %v\n", q.Service, err)
+		return
+	}
+	q.used = make(map[string]int)
+	for _, r := range rows {
+		q.used[r.Operation] = r.Units
+	}
+	// Whatever failed to write is still ours
+	for op, d := range q.pending {
+		q.used[op] += d.units
+	}
+	q.syncedAt = time.Now()
+}
+
+// flush upserts pending usage for q.day, keeping anything that fails for the
+// next attempt. Callers hold q.mu.
+func (q *QuotaLedger) flush() {
+	for op, d := range q.pending {
+		row := models.QuotaUsage{Service: q.Service, Day: q.day, Operation: op, Units: d.units, Calls: d.calls}
+		err := db.DB.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "service"}, {Name: "day"}, {Name: "operation"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"units":      gorm.Expr("quota_usages.units + ?", d.units),
+				"calls":      gorm.Expr("quota_usages.calls + ?", d.calls),
+				"updated_at": time.Now(),
+			}),
+		}).Create(&row).Error
+		if err != nil {
+			fmt.Printf("Failed to record %s quota usage: %v\n", q.Service, err)
+			continue
+		}
+		delete(q.pending, op)
+	}
+}
+
+// MarkExhausted is called when the provider rejects a call for quota reasons,
+// so we stop trying until the next reset even if our count disagrees.
+func (q *QuotaLedger) MarkExhausted() {
+	q.mu.Lock()
+	q.exhausted = q.today()
+	q.mu.Unlock()
+}
+
+// Status reports today's usage broken down by operation.
+func (q *QuotaLedger) Status() QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollDay(q.today())
+	if db.DB != nil {
+		q.syncIfDue()
+	}
+
+	status := QuotaStatus{
+		Service:    q.Service,
+		Day:        q.day,
+		Limit:      q.Limit(),
+		ResetsAt:   q.nextReset(),
+		Operations: make(map[string]int),
+	}
+	for op, units := range q.used {
+		status.Operations[op] = units
+		status.Used += units
+	}
+	if q.exhausted == q.day && status.Used < status.Limit {
+		status.Used = status.Limit
+	}
+	status.Remaining = max(status.Limit-status.Used, 0)
+	return status
+}
+
+func (q *QuotaLedger) Remaining() int {
+	return q.Status().Remaining
+}
+
+// CanAfford reports whether units can still be spent today. Replay always
+// can, since it spends nothing.
+func (q *QuotaLedger) CanAfford(units int) bool {
+	if httpMode() == HTTPReplay {
+		return true
+	}
+	return q.Remaining() >= units
+}
+
+func (q *QuotaLedger) nextReset() time.Time {
+	now := time.Now().In(q.Location)
+	y, m, d := now.Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, q.Location)
+}
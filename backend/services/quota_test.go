@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuotaLedgerInMemory(t *testing.T) {
+	t.Setenv("HTTP_MODE", HTTPLive)
+	q := &QuotaLedger{Service: "test", DailyLimit: 100, Location: time.UTC}
+
+	q.Spend("search", 50)
+	q.Spend("commentThreads", 1)
+	q.Spend("commentThreads", 1)
+
+	s := q.Status()
+	if s.Used != 52 || s.Remaining != 48 || s.Operations["commentThreads"] != 2 {
+		t.Errorf("Status() = %+v, want 52 used across two operations", s)
+	}
+	if !q.CanAfford(48) || q.CanAfford(49) {
+		t.Error("CanAfford disagrees with Remaining")
+	}
+
+	q.MarkExhausted()
+	if q.Remaining() != 0 {
+		t.Errorf("Remaining() after MarkExhausted = %d, want 0", q.Remaining())
+	}
+}
+
+func TestEstimateYouTubeRunCost(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want int
+	}{
+		// 10 videos x 1 page, 2 author lookups, search plus 5 probes
+		{"defaults", nil, 10 + 2 + 105},
+		{"no search", map[string]string{"YOUTUBE_SEARCH_ENABLED": "false"}, 10 + 2},
+		{"two pages a video", map[string]string{"YOUTUBE_SEARCH_ENABLED": "false", "YOUTUBE_MAX_COMMENTS_PER_VIDEO": "150"}, 20 + 2},
+		// Replies add up to 75 comments pages per video, capped by the run budget
+		{"replies", map[string]string{"YOUTUBE_SEARCH_ENABLED": "false", "YOUTUBE_INCLUDE_REPLIES": "true", "YOUTUBE_MAX_COMMENTS_PER_VIDEO": "150"}, 300},
+		{"replies under budget", map[string]string{"YOUTUBE_SEARCH_ENABLED": "false", "YOUTUBE_INCLUDE_REPLIES": "true", "YOUTUBE_MAX_COMMENTS_PER_VIDEO": "20", "YOUTUBE_WATCHLIST_VIDEOS": "1", "YOUTUBE_MAX_VIDEOS": "1"}, 2*(1+10) + 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"YOUTUBE_MAX_VIDEOS", "YOUTUBE_WATCHLIST_VIDEOS", "YOUTUBE_MAX_COMMENTS_PER_VIDEO", "YOUTUBE_INCLUDE_REPLIES", "YOUTUBE_SEARCH_ENABLED", "YOUTUBE_RUN_BUDGET"} {
+				t.Setenv(k, tt.env[k])
+			}
+			if got := EstimateYouTubeRunCost(); got != tt.want {
+				t.Errorf("EstimateYouTubeRunCost() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
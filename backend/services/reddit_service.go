@@ -1,72 +1,168 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
+// User-Agent is required by Reddit API
+const redditUserAgent = "go:election-pulse:v1.0 (by /u/cortex-ai)"
+
 type RedditResponse struct {
+	Data struct {
+		After    string `json:"after"` // Cursor for the next page, empty on the last one
+		Children []struct {
+			Data struct {
+				ID          string  `json:"id"`
+				Title       string  `json:"title"`
+				Selftext    string  `json:"selftext"`
+				Author      string  `json:"author"`
+				Url         string  `json:"url"`
+				Ups         int     `json:"ups"`
+				NumComments int     `json:"num_comments"`
+				Created     float64 `json:"created_utc"`
+				Subreddit   string  `json:"subreddit"`
+			} `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+// redditCommentListing is one level of a /comments/<id>.json tree. Children
+// are either comments ("t1") or "more" stubs we don't expand.
+type redditCommentListing struct {
 	Data struct {
 		Children []struct {
+			Kind string `json:"kind"`
 			Data struct {
-				Title     string  `json:"title"`
-				Selftext  string  `json:"selftext"`
-				Author    string  `json:"author"`
-				Url       string  `json:"url"`
-				Ups       int     `json:"ups"`
-				Created   float64 `json:"created_utc"`
-				Subreddit string  `json:"subreddit"`
+				ID       string          `json:"id"`
+				ParentID string          `json:"parent_id"`
+				Author   string          `json:"author"`
+				Body     string          `json:"body"`
+				Score    int             `json:"score"`
+				Created  float64         `json:"created_utc"`
+				Depth    int             `json:"depth"`
+				Replies  json.RawMessage `json:"replies"` // "" when there are none
 			} `json:"data"`
 		} `json:"children"`
 	} `json:"data"`
 }
 
 type RedditPost struct {
-	Title     string
-	Text      string
-	URL       string
-	Subreddit string
+	ID              string
+	Title           string
+	Text            string
+	URL             string
+	Subreddit       string
+	Author          string
+	Score           int
+	NumComments     int
+	CreatedAt       time.Time
+	AuthorKarma     int       // Link + comment karma, 0 if unknown
+	AuthorCreatedAt time.Time // Zero if the profile lookup was skipped
+	Comments        []RedditComment
+	TargetID        uint // source_targets row the post came from, 0 if none
+
+	// Set by DetectInauthentic
+	Suspicion float64
+	Flags     []string
 }
 
-func FetchRedditPosts(query string) ([]RedditPost, error) {
-	// Subreddits to search
-	subreddits := []string{"TamilNadu", "Chennai", "India"}
-	var allPosts []RedditPost
-	client := &http.Client{Timeout: 10 * time.Second}
+type RedditComment struct {
+	ID              string
+	PostID          string
+	ParentID        string // t1_<id> for replies, t3_<post id> for top-level comments
+	Author          string
+	Body            string
+	Score           int
+	Depth           int // 0 for top-level comments
+	CreatedAt       time.Time
+	AuthorKarma     int
+	AuthorCreatedAt time.Time
 
-	for _, sub := range subreddits {
-		// Construct URL: https://www.reddit.com/r/{subreddit}/search.json?q={query}&restrict_sr=1&sort=new&limit=5
-		// Need validation/encoding
-		encodedQuery := url.QueryEscape(query)
-		url := fmt.Sprintf("https://www.reddit.com/r/%s/search.json?q=%s&restrict_sr=1&sort=new&limit=5", sub, encodedQuery)
+	// Set by DetectInauthentic
+	Suspicion float64
+	Flags     []string
+}
 
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			fmt.Printf("Error creating Reddit request for r/%s: %v\n", sub, err)
-			continue
-		}
+type redditAboutResponse struct {
+	Data struct {
+		Created      float64 `json:"created_utc"`
+		LinkKarma    int     `json:"link_karma"`
+		CommentKarma int     `json:"comment_karma"`
+	} `json:"data"`
+}
 
-		// User-Agent is required by Reddit API
-		req.Header.Set("User-Agent", "go:election-pulse:v1.0 (by /u/cortex-ai)")
+// redditConfig bounds how much of each comment tree we pull per run.
+type redditConfig struct {
+	PostsWithComments int // Matched posts whose comment trees are fetched
+	CommentsPerPost   int
+	CommentDepth      int // Reply levels below the top-level comments
+}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			fmt.Printf("Error fetching from r/%s: %v\n", sub, err)
-			continue
-		}
-		defer resp.Body.Close()
+func loadRedditConfig() redditConfig {
+	return redditConfig{
+		PostsWithComments: envInt("REDDIT_POSTS_WITH_COMMENTS", 10),
+		CommentsPerPost:   envInt("REDDIT_COMMENTS_PER_POST", 20),
+		CommentDepth:      envInt("REDDIT_COMMENT_DEPTH", 2),
+	}
+}
 
-		if resp.StatusCode != 200 {
-			fmt.Printf("Reddit API error r/%s: %d\n", sub, resp.StatusCode)
-			continue
+// redditPause spaces out requests to stay under Reddit's unauthenticated rate
+// limit (REDDIT_PAUSE). 429s that still happen are retried by the shared
+// transport according to Retry-After. It returns early with the context
+// error if ctx is cancelled.
+func redditPause(ctx context.Context) error {
+	return sleepCtx(ctx, envDuration("REDDIT_PAUSE", 500*time.Millisecond))
+}
+
+func redditGet(ctx context.Context, client *http.Client, reqURL string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", redditUserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("reddit api error: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func FetchRedditPosts(ctx context.Context, query string) ([]RedditPost, error) {
+	// Subreddits to search come from source_targets
+	subreddits := ResolveTargets(TargetSubreddit, partyIDByName(query), "")
+	var allPosts []RedditPost
+	var errs []error
+	client := newHTTPClient(10 * time.Second)
+	cfg := loadRedditConfig()
+
+	for _, target := range subreddits {
+		sub := strings.TrimPrefix(target.Value, "r/")
+		if ctx.Err() != nil {
+			return allPosts, ctx.Err()
 		}
 
+		// Construct URL: https://www.reddit.com/r/{subreddit}/search.json?q={query}&restrict_sr=1&sort=new&limit=5
+		encodedQuery := url.QueryEscape(query)
+		searchURL := fmt.Sprintf("https://www.reddit.com/r/%s/search.json?q=%s&restrict_sr=1&sort=new&limit=5", url.PathEscape(sub), encodedQuery)
+
 		var redditResp RedditResponse
-		if err := json.NewDecoder(resp.Body).Decode(&redditResp); err != nil {
-			fmt.Printf("Error decoding Reddit response r/%s: %v\n", sub, err)
+		if err := redditGet(ctx, client, searchURL, &redditResp); err != nil {
+			fmt.Printf("Error fetching from r/%s: %v\n", sub, err)
+			errs = append(errs, fmt.Errorf("r/%s: %w", sub, err))
 			continue
 		}
 
@@ -75,18 +171,279 @@ func FetchRedditPosts(query string) ([]RedditPost, error) {
 			// Basic filtering so we don't capture empty stuff
 			if post.Title != "" {
 				allPosts = append(allPosts, RedditPost{
-					Title:     post.Title,
-					Text:      post.Selftext,
-					URL:       post.Url,
-					Subreddit: post.Subreddit,
+					ID:          post.ID,
+					Title:       post.Title,
+					Text:        post.Selftext,
+					URL:         post.Url,
+					Subreddit:   post.Subreddit,
+					Author:      post.Author,
+					Score:       post.Ups,
+					NumComments: post.NumComments,
+					CreatedAt:   time.Unix(int64(post.Created), 0).UTC(),
+					TargetID:    target.ID,
 				})
 			}
 		}
 
 		// Respect rate limiting slightly
-		time.Sleep(500 * time.Millisecond)
+		if err := redditPause(ctx); err != nil {
+			return allPosts, err
+		}
+	}
+
+	if len(subreddits) > 0 && len(errs) == len(subreddits) {
+		return nil, fmt.Errorf("all %d subreddits failed: %w", len(subreddits), errors.Join(errs...))
+	}
+
+	// Most of the sentiment lives in the threads, not the submissions
+	fetched, err := attachRedditComments(ctx, client, allPosts, cfg)
+	if err != nil {
+		return allPosts, err
 	}
 
-	fmt.Printf("Fetched %d Reddit posts for '%s'\n", len(allPosts), query)
+	if err := lookupRedditAuthors(ctx, client, allPosts); err != nil {
+		return allPosts, err
+	}
+
+	fmt.Printf("Fetched %d Reddit posts (%d with comment threads) for '%s'\n", len(allPosts), fetched, query)
 	return allPosts, nil
 }
+
+// attachRedditComments fetches comment trees for up to cfg.PostsWithComments
+// posts and returns how many threads were fetched.
+func attachRedditComments(ctx context.Context, client *http.Client, posts []RedditPost, cfg redditConfig) (int, error) {
+	fetched := 0
+	for i := range posts {
+		if fetched >= cfg.PostsWithComments {
+			break
+		}
+		if posts[i].NumComments == 0 {
+			continue
+		}
+
+		comments, err := fetchRedditComments(ctx, client, posts[i].ID, cfg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return fetched, ctx.Err()
+			}
+			fmt.Printf("Error fetching comments for post %s: %v\n", posts[i].ID, err)
+			continue
+		}
+		posts[i].Comments = comments
+		fetched++
+
+		if err := redditPause(ctx); err != nil {
+			return fetched, err
+		}
+	}
+	return fetched, nil
+}
+
+// FetchRedditRange collects posts created in [from, to) by paging back
+// through each subreddit's newest search results. Reddit only serves the most
+// recent ~1000 results per listing, so very old ranges may come back sparse.
+// Comments created after to are dropped so the thread reflects the period.
+func FetchRedditRange(ctx context.Context, query string, from, to time.Time) ([]RedditPost, error) {
+	subreddits := ResolveTargets(TargetSubreddit, partyIDByName(query), "")
+	client := newHTTPClient(10 * time.Second)
+	cfg := loadRedditConfig()
+	maxPages := envInt("REDDIT_BACKFILL_PAGES", 5)
+	var posts []RedditPost
+	var errs []error
+
+	for _, target := range subreddits {
+		sub := strings.TrimPrefix(target.Value, "r/")
+		after := ""
+
+		for page := 0; page < maxPages; page++ {
+			searchURL := fmt.Sprintf("https://www.reddit.com/r/%s/search.json?q=%s&restrict_sr=1&sort=new&t=all&limit=100&after=%s",
+				url.PathEscape(sub), url.QueryEscape(query), url.QueryEscape(after))
+
+			var redditResp RedditResponse
+			if err := redditGet(ctx, client, searchURL, &redditResp); err != nil {
+				if ctx.Err() != nil {
+					return posts, ctx.Err()
+				}
+				fmt.Printf("Error fetching from r/%s: %v\n", sub, err)
+				errs = append(errs, fmt.Errorf("r/%s: %w", sub, err))
+				break
+			}
+
+			reachedStart := false
+			for _, child := range redditResp.Data.Children {
+				post := child.Data
+				created := time.Unix(int64(post.Created), 0).UTC()
+				if created.Before(from) {
+					reachedStart = true
+					break
+				}
+				if post.Title == "" || !created.Before(to) {
+					continue
+				}
+				posts = append(posts, RedditPost{
+					ID:          post.ID,
+					Title:       post.Title,
+					Text:        post.Selftext,
+					URL:         post.Url,
+					Subreddit:   post.Subreddit,
+					Author:      post.Author,
+					Score:       post.Ups,
+					NumComments: post.NumComments,
+					CreatedAt:   created,
+					TargetID:    target.ID,
+				})
+			}
+
+			if err := redditPause(ctx); err != nil {
+				return posts, err
+			}
+			if reachedStart || redditResp.Data.After == "" {
+				break
+			}
+			after = redditResp.Data.After
+		}
+	}
+
+	// Keep whatever earlier pages returned, so buckets they cover can still
+	// be scored as partial
+	if len(subreddits) > 0 && len(errs) == len(subreddits) {
+		return posts, fmt.Errorf("all %d subreddits failed: %w", len(subreddits), errors.Join(errs...))
+	}
+
+	if _, err := attachRedditComments(ctx, client, posts, cfg); err != nil {
+		return posts, err
+	}
+	for i := range posts {
+		var kept []RedditComment
+		for _, c := range posts[i].Comments {
+			if c.CreatedAt.Before(to) {
+				kept = append(kept, c)
+			}
+		}
+		posts[i].Comments = kept
+	}
+
+	fmt.Printf("Fetched %d Reddit posts for '%s' between %s and %s\n", len(posts), query,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	return posts, nil
+}
+
+// fetchRedditComments pulls the top comments of a post, walking replies down
+// to cfg.CommentDepth and stopping at cfg.CommentsPerPost.
+func fetchRedditComments(ctx context.Context, client *http.Client, postID string, cfg redditConfig) ([]RedditComment, error) {
+	commentsURL := fmt.Sprintf("https://www.reddit.com/comments/%s.json?sort=top&limit=%d&depth=%d",
+		url.PathEscape(postID), cfg.CommentsPerPost, cfg.CommentDepth+1)
+
+	// The endpoint returns [post listing, comment listing]
+	var listings []redditCommentListing
+	if err := redditGet(ctx, client, commentsURL, &listings); err != nil {
+		return nil, err
+	}
+	if len(listings) < 2 {
+		return nil, nil
+	}
+
+	var comments []RedditComment
+	var walk func(l redditCommentListing)
+	walk = func(l redditCommentListing) {
+		for _, child := range l.Data.Children {
+			if len(comments) >= cfg.CommentsPerPost {
+				return
+			}
+			if child.Kind != "t1" {
+				continue
+			}
+			c := child.Data
+			if c.Body != "" && c.Body != "[deleted]" && c.Body != "[removed]" {
+				comments = append(comments, RedditComment{
+					ID:        c.ID,
+					PostID:    postID,
+					ParentID:  c.ParentID,
+					Author:    c.Author,
+					Body:      c.Body,
+					Score:     c.Score,
+					Depth:     c.Depth,
+					CreatedAt: time.Unix(int64(c.Created), 0).UTC(),
+				})
+			}
+
+			if c.Depth < cfg.CommentDepth && bytes.HasPrefix(bytes.TrimSpace(c.Replies), []byte("{")) {
+				var replies redditCommentListing
+				if err := json.Unmarshal(c.Replies, &replies); err == nil {
+					walk(replies)
+				}
+			}
+		}
+	}
+	walk(listings[1])
+
+	return comments, nil
+}
+
+// maxRedditAuthorLookups caps the per-run profile lookups; each one is a
+// separate request against Reddit's unauthenticated rate limit.
+const maxRedditAuthorLookups = 10
+
+// lookupRedditAuthors fills karma and account age so DetectInauthentic can
+// spot throwaway accounts. Post authors are looked up before commenters.
+// Failures are logged and leave the fields zero.
+func lookupRedditAuthors(ctx context.Context, client *http.Client, posts []RedditPost) error {
+	type profile struct {
+		karma   int
+		created time.Time
+	}
+	profiles := make(map[string]profile)
+
+	var authors []string
+	for _, p := range posts {
+		authors = append(authors, p.Author)
+	}
+	for _, p := range posts {
+		for _, c := range p.Comments {
+			authors = append(authors, c.Author)
+		}
+	}
+
+	for _, author := range authors {
+		if len(profiles) >= maxRedditAuthorLookups {
+			break
+		}
+		if author == "" || author == "[deleted]" {
+			continue
+		}
+		if _, ok := profiles[author]; ok {
+			continue
+		}
+
+		var about redditAboutResponse
+		aboutURL := fmt.Sprintf("https://www.reddit.com/user/%s/about.json", url.PathEscape(author))
+		if err := redditGet(ctx, client, aboutURL, &about); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			// Suspended or shadowbanned accounts 404 here; remember that we tried
+			profiles[author] = profile{}
+			continue
+		}
+
+		profiles[author] = profile{
+			karma:   about.Data.LinkKarma + about.Data.CommentKarma,
+			created: time.Unix(int64(about.Data.Created), 0).UTC(),
+		}
+	}
+
+	for i := range posts {
+		if pr, ok := profiles[posts[i].Author]; ok {
+			posts[i].AuthorKarma = pr.karma
+			posts[i].AuthorCreatedAt = pr.created
+		}
+		for j := range posts[i].Comments {
+			c := &posts[i].Comments[j]
+			if pr, ok := profiles[c.Author]; ok {
+				c.AuthorKarma = pr.karma
+				c.AuthorCreatedAt = pr.created
+			}
+		}
+	}
+	return nil
+}
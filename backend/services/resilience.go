@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source names used for circuit breakers and fetch status. RSS feeds and
+// article pages get a breaker per host instead, so one dead site doesn't take
+// out the others.
+const (
+	SourceRSS      = "rss"
+	SourceNewsData = "newsdata"
+	SourceYouTube  = "youtube"
+	SourceReddit   = "reddit"
+	SourceGemini   = "gemini"
+)
+
+// Breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+var errCircuitOpen = errors.New("circuit open")
+
+// CircuitBreaker stops calling a source after repeated failures. Once the
+// cooldown has passed a single probe is let through; success closes the
+// breaker again, failure reopens it.
+type CircuitBreaker struct {
+	Name      string
+	Threshold int // Consecutive failures that open the breaker
+	Cooldown  time.Duration
+
+	mu          sync.Mutex
+	state       string
+	failures    int
+	openedAt    time.Time
+	lastError   string
+	lastFailure time.Time
+	probing     bool
+}
+
+type BreakerStatus struct {
+	Name        string     `json:"name"`
+	State       string     `json:"state"`
+	Failures    int        `json:"consecutive_failures"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastFailure *time.Time `json:"last_failure,omitempty"`
+	RetryAt     *time.Time `json:"retry_at,omitempty"` // When an open breaker lets a probe through
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*CircuitBreaker)
+)
+
+// Breaker returns the shared breaker for a source, creating it on first use.
+func Breaker(name string) *CircuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[name]
+	if !ok {
+		b = &CircuitBreaker{
+			Name:      name,
+			Threshold: envInt("BREAKER_FAILURES", 5),
+			Cooldown:  envDuration("BREAKER_COOLDOWN", 2*time.Minute),
+			state:     BreakerClosed,
+		}
+		breakers[name] = b
+	}
+	return b
+}
+
+// BreakerStatuses lists every breaker that has seen traffic, by name.
+func BreakerStatuses() []BreakerStatus {
+	breakersMu.Lock()
+	list := make([]*CircuitBreaker, 0, len(breakers))
+	for _, b := range breakers {
+		list = append(list, b)
+	}
+	breakersMu.Unlock()
+
+	out := make([]BreakerStatus, 0, len(list))
+	for _, b := range list {
+		out = append(out, b.Status())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ResetBreaker closes a breaker by hand, e.g. after fixing an API key.
+func ResetBreaker(name string) bool {
+	breakersMu.Lock()
+	b, ok := breakers[name]
+	breakersMu.Unlock()
+	if ok {
+		b.mu.Lock()
+		b.state, b.failures, b.probing = BreakerClosed, 0, false
+		b.mu.Unlock()
+	}
+	return ok
+}
+
+// Allow reports whether a call may go ahead. In the half-open state only one
+// probe is allowed at a time.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// IsOpen reports whether callers should skip the source entirely. Unlike
+// Allow it never uses up the half-open probe.
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == BreakerOpen && time.Since(b.openedAt) < b.Cooldown
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.probing = BreakerClosed, 0, false
+}
+
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastError = err.Error()
+	b.lastFailure = time.Now()
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.Threshold {
+		if b.state != BreakerOpen {
+			fmt.Printf("Circuit breaker for %s opened after %d failures: %v\n", b.Name, b.failures, err)
+		}
+		b.state = BreakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *CircuitBreaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BreakerStatus{Name: b.Name, State: b.state, Failures: b.failures, LastError: b.lastError}
+	if !b.lastFailure.IsZero() {
+		t := b.lastFailure
+		s.LastFailure = &t
+	}
+	if b.state == BreakerOpen {
+		t := b.openedAt.Add(b.Cooldown)
+		s.RetryAt = &t
+	}
+	return s
+}
+
+// sourceForHost maps a request host to its breaker name.
+func sourceForHost(host string) string {
+	host = strings.ToLower(host)
+	switch {
+	case strings.HasSuffix(host, "reddit.com"):
+		return SourceReddit
+	case strings.HasSuffix(host, "newsdata.io"):
+		return SourceNewsData
+	case host == "generativelanguage.googleapis.com":
+		return SourceGemini
+	case host == "www.googleapis.com" || host == "youtube.googleapis.com":
+		return SourceYouTube
+	}
+	return strings.TrimPrefix(host, "www.")
+}
+
+// retryTransport retries transient failures (network errors, 429 and 5xx)
+// with jittered exponential backoff, honours Retry-After, and feeds the
+// outcome into the source's circuit breaker.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+	maxWait    time.Duration // Longest Retry-After we are willing to sit out
+}
+
+func newRetryTransport(next http.RoundTripper) *retryTransport {
+	return &retryTransport{
+		next:       next,
+		maxRetries: envInt("HTTP_MAX_RETRIES", 2),
+		baseDelay:  envDuration("HTTP_RETRY_BASE", 500*time.Millisecond),
+		maxWait:    envDuration("HTTP_MAX_RETRY_WAIT", 10*time.Second),
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := Breaker(sourceForHost(req.URL.Host))
+	if !breaker.Allow() {
+		return nil, fmt.Errorf("%s: %w", breaker.Name, errCircuitOpen)
+	}
+
+	// Bodies can only be resent if the request knows how to rebuild them
+	retries := t.maxRetries
+	if req.Body != nil && req.GetBody == nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+
+		if req.Context().Err() != nil {
+			// Our own deadline, not the source's fault
+			breaker.release()
+			return resp, err
+		}
+		if !isTransient(resp, err) {
+			breaker.Success()
+			return resp, err
+		}
+
+		reason := err
+		if reason == nil {
+			reason = fmt.Errorf("http error: %s", resp.Status)
+		}
+		wait, ok := t.backoff(attempt, resp)
+		if attempt >= retries || !ok {
+			// Hand the last response back as is; callers check the status
+			breaker.Failure(reason)
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		fmt.Printf("%s %s failed (%v), retrying in %s\n", req.Method, redactURL(req.URL), reason, wait.Round(time.Millisecond))
+		if err := sleepCtx(req.Context(), wait); err != nil {
+			breaker.release()
+			return nil, err
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// release gives back a half-open probe that ended without a verdict.
+func (b *CircuitBreaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// isTransient reports whether a failed call is worth retrying.
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// backoff returns how long to wait before the next attempt. ok is false when
+// the server asked us to wait longer than maxWait, in which case retrying
+// within this request is pointless.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) (wait time.Duration, ok bool) {
+	if resp != nil {
+		if d, found := parseRetryAfter(resp.Header.Get("Retry-After")); found {
+			return d, d <= t.maxWait
+		}
+	}
+	// Full jitter: anywhere between 0 and base*2^attempt
+	ceiling := t.baseDelay << attempt
+	return time.Duration(rand.Int63n(int64(ceiling) + 1)), true
+}
+
+// parseRetryAfter reads either form of Retry-After: delay seconds or an HTTP
+// date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
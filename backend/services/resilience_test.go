@@ -0,0 +1,34 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"30", 30 * time.Second, true},
+		{"-5", 0, true},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	// HTTP dates are relative to now, so only check the range
+	got, ok := parseRetryAfter(time.Now().Add(2 * time.Minute).UTC().Format(http.TimeFormat))
+	if !ok || got <= time.Minute || got > 2*time.Minute {
+		t.Errorf("parseRetryAfter(date in 2m) = %v, %v", got, ok)
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// resultColumns maps accepted CSV headers to the field they fill. Published
+// datasets name these differently, so the common spellings are all taken.
+var resultColumns = map[string]string{
+	"constituency_no": "number", "ac_no": "number", "no": "number", "number": "number",
+	"constituency": "name", "ac_name": "name", "constituency_name": "name", "name": "name",
+	"district": "district", "region": "region",
+	"reserved": "reserved", "type": "reserved", "category": "reserved",
+	"party": "party", "party_name": "party",
+	"candidate": "candidate", "candidate_name": "candidate",
+	"votes": "votes", "total_votes": "votes",
+}
+
+// ImportSummary reports what an import did.
+type ImportSummary struct {
+	Year           int      `json:"year"`
+	Constituencies int      `json:"constituencies"`
+	Rows           int      `json:"rows"`
+	Unmatched      []string `json:"unmatched_parties"` // Party names counted as Others
+}
+
+// SeatResult is one constituency's result as read from a results file, with
+// vote shares and the winner filled in and parties not yet resolved.
+type SeatResult struct {
+	Seat    models.Constituency
+	Results []models.ElectionResult
+}
+
+// ParsedResults is a results file read into memory.
+type ParsedResults struct {
+	Year  int
+	Seats []SeatResult // By constituency number
+	Rows  int          // Candidate rows read, NOTA excluded
+}
+
+// ParseResults reads one election's constituency results from CSV, one row
+// per candidate. Candidates of the same party in one seat (or several
+// independents) are summed; vote shares and winners are computed here.
+func ParseResults(r io.Reader, year int) (*ParsedResults, error) {
+	if year < 1952 || year > 2100 {
+		return nil, fmt.Errorf("invalid election year %d", year)
+	}
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	col := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if field, ok := resultColumns[h]; ok {
+			if _, dup := col[field]; !dup {
+				col[field] = i
+			}
+		}
+	}
+	for _, need := range []string{"number", "name", "party", "votes"} {
+		if _, ok := col[need]; !ok {
+			return nil, fmt.Errorf("missing column for %s (header: %s)", need, strings.Join(header, ","))
+		}
+	}
+	get := func(rec []string, field string) string {
+		i, ok := col[field]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	type seatRows struct {
+		seat    models.Constituency
+		byParty map[string]*models.ElectionResult
+		best    map[string]int // Votes of the party's strongest candidate
+		order   []string
+	}
+	seats := make(map[int]*seatRows)
+	parsed := &ParsedResults{Year: year}
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		number, err := strconv.Atoi(get(rec, "number"))
+		if err != nil || number <= 0 {
+			return nil, fmt.Errorf("line %d: invalid constituency number %q", line, get(rec, "number"))
+		}
+		votes, err := strconv.Atoi(strings.ReplaceAll(get(rec, "votes"), ",", ""))
+		if err != nil || votes < 0 {
+			return nil, fmt.Errorf("line %d: invalid votes %q", line, get(rec, "votes"))
+		}
+		party := get(rec, "party")
+		if party == "" {
+			party = "IND"
+		}
+		if strings.EqualFold(party, "NOTA") {
+			continue // Not a contender, and shares are of votes for candidates
+		}
+		parsed.Rows++
+
+		s := seats[number]
+		if s == nil {
+			s = &seatRows{
+				seat: models.Constituency{
+					Number:   number,
+					Name:     get(rec, "name"),
+					District: get(rec, "district"),
+					Region:   get(rec, "region"),
+					Reserved: strings.ToUpper(strings.Trim(get(rec, "reserved"), "()")),
+				},
+				byParty: make(map[string]*models.ElectionResult),
+				best:    make(map[string]int),
+			}
+			if s.seat.Reserved == "GEN" || s.seat.Reserved == "GENERAL" {
+				s.seat.Reserved = ""
+			}
+			seats[number] = s
+		}
+		key := strings.ToUpper(party)
+		res := s.byParty[key]
+		if res == nil {
+			res = &models.ElectionResult{Year: year, PartyName: party}
+			s.byParty[key] = res
+			s.order = append(s.order, key)
+		}
+		if c := get(rec, "candidate"); c != "" && (res.Candidate == "" || votes > s.best[key]) {
+			res.Candidate = c
+			s.best[key] = votes
+		}
+		res.Votes += votes
+	}
+	if len(seats) == 0 {
+		return nil, fmt.Errorf("no result rows in file")
+	}
+
+	numbers := make([]int, 0, len(seats))
+	for n := range seats {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		s := seats[n]
+		total, winner := 0, ""
+		for _, key := range s.order {
+			r := s.byParty[key]
+			total += r.Votes
+			if winner == "" || r.Votes > s.byParty[winner].Votes {
+				winner = key
+			}
+		}
+		sr := SeatResult{Seat: s.seat, Results: make([]models.ElectionResult, 0, len(s.order))}
+		for _, key := range s.order {
+			r := *s.byParty[key]
+			if total > 0 {
+				r.VoteShare = 100 * float64(r.Votes) / float64(total)
+			}
+			r.Won = key == winner
+			sr.Results = append(sr.Results, r)
+		}
+		parsed.Seats = append(parsed.Seats, sr)
+	}
+	return parsed, nil
+}
+
+// ImportResults loads one election's constituency results from CSV (see
+// ParseResults). Constituencies are created or updated by number, and the
+// year's previous results are replaced, so re-importing a corrected file is
+// safe.
+func ImportResults(r io.Reader, year int) (*ImportSummary, error) {
+	parsed, err := ParseResults(r, year)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve party names once each
+	partyIDs := make(map[string]*uint)
+	summary := &ImportSummary{Year: year, Constituencies: len(parsed.Seats), Rows: parsed.Rows}
+	for _, s := range parsed.Seats {
+		for _, res := range s.Results {
+			key := strings.ToUpper(res.PartyName)
+			if _, done := partyIDs[key]; done {
+				continue
+			}
+			if p, err := ResolveParty(res.PartyName); err == nil {
+				id := p.ID
+				partyIDs[key] = &id
+			} else {
+				partyIDs[key] = nil
+				summary.Unmatched = append(summary.Unmatched, res.PartyName)
+			}
+		}
+	}
+	sort.Strings(summary.Unmatched)
+
+	err = db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("year = ?", year).Delete(&models.ElectionResult{}).Error; err != nil {
+			return err
+		}
+		for _, s := range parsed.Seats {
+			seat := s.Seat
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "number"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "district", "region", "reserved", "updated_at"}),
+			}).Create(&seat).Error
+			if err != nil {
+				return err
+			}
+			if seat.ID == 0 {
+				if err := tx.Where("number = ?", seat.Number).First(&seat).Error; err != nil {
+					return err
+				}
+			}
+
+			results := make([]models.ElectionResult, len(s.Results))
+			for i, r := range s.Results {
+				r.ConstituencyID = seat.ID
+				r.PartyID = partyIDs[strings.ToUpper(r.PartyName)]
+				results[i] = r
+			}
+			if err := tx.Create(&results).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// LatestResultYear is the most recent election with imported results, or 0.
+func LatestResultYear() int {
+	var year int
+	db.DB.Model(&models.ElectionResult{}).Select("COALESCE(MAX(year), 0)").Scan(&year)
+	return year
+}
+
+// LoadResults reads an imported election back in the shape ParseResults
+// gives, for comparing against.
+func LoadResults(year int) (*ParsedResults, error) {
+	var results []models.ElectionResult
+	if err := db.DB.Where("year = ?", year).Order("constituency_id, votes desc").Find(&results).Error; err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w for %d", ErrNoResults, year)
+	}
+	var constituencies []models.Constituency
+	if err := db.DB.Order("number").Find(&constituencies).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint][]models.ElectionResult)
+	for _, r := range results {
+		byID[r.ConstituencyID] = append(byID[r.ConstituencyID], r)
+	}
+	parsed := &ParsedResults{Year: year, Rows: len(results)}
+	for _, c := range constituencies {
+		if rs := byID[c.ID]; len(rs) > 0 {
+			parsed.Seats = append(parsed.Seats, SeatResult{Seat: c, Results: rs})
+		}
+	}
+	return parsed, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseResults(t *testing.T) {
+	type result struct {
+		party     string
+		candidate string
+		votes     int
+		share     float64
+		won       bool
+	}
+	tests := []struct {
+		name    string
+		csv     string
+		year    int
+		wantErr string
+		rows    int
+		seats   map[int][]result
+	}{
+		{
+			name: "basic",
+			csv: "\ufeffAC_No,AC_Name,District,Type,Party,Candidate,Votes\n" +
+				"1,Gummidipoondi,Tiruvallur,GEN,DMK,A,\"60,000\"\n" +
+				"1,Gummidipoondi,Tiruvallur,GEN,AIADMK,B,40000\n" +
+				"1,Gummidipoondi,Tiruvallur,GEN,NOTA,,1500\n" +
+				"2,Ponneri,Tiruvallur,(SC),AIADMK,C,50000\n" +
+				"2,Ponneri,Tiruvallur,(SC),,D,20000\n" +
+				"2,Ponneri,Tiruvallur,(SC),IND,E,30000\n",
+			year: 2021,
+			rows: 5,
+			seats: map[int][]result{
+				1: {{"DMK", "A", 60000, 60, true}, {"AIADMK", "B", 40000, 40, false}},
+				2: {{"AIADMK", "C", 50000, 50, true}, {"IND", "E", 50000, 50, false}},
+			},
+		},
+		{
+			name:    "missing votes column",
+			csv:     "constituency_no,constituency,party\n1,Gummidipoondi,DMK\n",
+			year:    2021,
+			wantErr: "missing column for votes",
+		},
+		{
+			name:    "bad number",
+			csv:     "no,name,party,votes\nx,Gummidipoondi,DMK,10\n",
+			year:    2021,
+			wantErr: "line 2: invalid constituency number",
+		},
+		{
+			name:    "bad votes",
+			csv:     "no,name,party,votes\n1,Gummidipoondi,DMK,-10\n",
+			year:    2021,
+			wantErr: "line 2: invalid votes",
+		},
+		{
+			name:    "no rows",
+			csv:     "no,name,party,votes\n",
+			year:    2021,
+			wantErr: "no result rows",
+		},
+		{
+			name:    "bad year",
+			csv:     "no,name,party,votes\n1,Gummidipoondi,DMK,10\n",
+			year:    21,
+			wantErr: "invalid election year",
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseResults(strings.NewReader(tt.csv), tt.year)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.Rows != tt.rows || len(got.Seats) != len(tt.seats) {
+			t.Errorf("%s: %d rows in %d seats, want %d in %d", tt.name, got.Rows, len(got.Seats), tt.rows, len(tt.seats))
+			continue
+		}
+		for _, s := range got.Seats {
+			want := tt.seats[s.Seat.Number]
+			if len(s.Results) != len(want) {
+				t.Errorf("%s: seat %d has %d results, want %d", tt.name, s.Seat.Number, len(s.Results), len(want))
+				continue
+			}
+			for i, r := range s.Results {
+				w := want[i]
+				if r.PartyName != w.party || r.Candidate != w.candidate || r.Votes != w.votes || !near(r.VoteShare, w.share) || r.Won != w.won {
+					t.Errorf("%s: seat %d result %d = %+v, want %+v", tt.name, s.Seat.Number, i, r, w)
+				}
+			}
+		}
+	}
+}
+
+func TestParseResultsSeat(t *testing.T) {
+	csv := "no,name,district,reserved,party,votes\n" +
+		"1,Gummidipoondi,Tiruvallur,GEN,DMK,10\n" +
+		"2,Ponneri,Tiruvallur,(sc),DMK,10\n"
+	got, err := ParseResults(strings.NewReader(csv), 2021)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		number   int
+		name     string
+		reserved string
+	}{
+		{1, "Gummidipoondi", ""},
+		{2, "Ponneri", "SC"},
+	}
+	for i, tt := range tests {
+		s := got.Seats[i].Seat
+		if s.Number != tt.number || s.Name != tt.name || s.District != "Tiruvallur" || s.Reserved != tt.reserved {
+			t.Errorf("seat %d = %+v", tt.number, s)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+
+	"github.com/mmcdole/gofeed"
+	"gorm.io/gorm/clause"
+)
+
+const rssUserAgent = "election-pulse/1.0 (+https://github.com/devtitus/Digital-Election-Pulse)"
+
+var errFeedDisabled = errors.New("feed disabled after repeated failures")
+
+type feedResult struct {
+	feed         *gofeed.Feed // nil when the server answered 304 Not Modified
+	etag         string
+	lastModified string
+}
+
+// fetchFeed downloads and parses a feed, sending the validators from the
+// previous fetch so unchanged feeds come back as an empty 304.
+func fetchFeed(ctx context.Context, feedURL, etag, lastModified string) (*feedResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, envDuration("RSS_TIMEOUT", 10*time.Second))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", rssUserAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := newHTTPClient(0).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res := &feedResult{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}
+	if resp.StatusCode == http.StatusNotModified {
+		// Some servers omit validators on 304; keep the ones we sent
+		if res.etag == "" {
+			res.etag = etag
+		}
+		if res.lastModified == "" {
+			res.lastModified = lastModified
+		}
+		return res, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("http error: %s", resp.Status)
+	}
+
+	res.feed, err = gofeed.NewParser().Parse(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ingestFeed refreshes one registered feed and returns its latest stored
+// items. Only items whose GUID we haven't seen are written, and fetch health
+// is recorded either way.
+func ingestFeed(ctx context.Context, feedURL, source string, targetID uint) ([]NewsItem, error) {
+	feed := models.Feed{URL: feedURL}
+	if err := db.DB.Where(models.Feed{URL: feedURL}).Attrs(models.Feed{Source: source, TargetID: targetID}).
+		FirstOrCreate(&feed).Error; err != nil {
+		return nil, err
+	}
+	if feed.Disabled {
+		return nil, errFeedDisabled
+	}
+
+	start := time.Now()
+	res, err := fetchFeed(ctx, feedURL, feed.ETag, feed.LastModified)
+	if ctx.Err() == nil {
+		// A run that ran out of time says nothing about the feed
+		recordFeedHealth(&feed, time.Since(start), err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	feed.ETag, feed.LastModified = res.etag, res.lastModified
+	db.DB.Model(&feed).Select("ETag", "LastModified").Updates(&feed)
+
+	if res.feed != nil {
+		newItems := storeFeedItems(feed.ID, res.feed.Items)
+		if newItems > 0 {
+			fmt.Printf("%s: %d new items\n", source, newItems)
+		}
+	}
+
+	var stored []models.FeedItem
+	if err := db.DB.Where("feed_id = ?", feed.ID).Order("published_at desc nulls last").Limit(perFeedLimit).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	var items []NewsItem
+	for _, it := range stored {
+		var published time.Time
+		if it.PublishedAt != nil {
+			published = *it.PublishedAt
+		}
+		items = append(items, NewsItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			Description: it.Description,
+			Content:     it.Content,
+			Body:        it.Body,
+			PublishedAt: published,
+			DateUnknown: it.PublishedAt == nil,
+			Source:      source,
+			TargetID:    targetID,
+			FeedItemID:  it.ID,
+		})
+	}
+	return items, nil
+}
+
+// storeFeedItems inserts unseen items and returns how many were new.
+func storeFeedItems(feedID uint, items []*gofeed.Item) int {
+	now := time.Now()
+	var rows []models.FeedItem
+	for _, item := range items {
+		var published *time.Time
+		if t, ok := feedItemDate(item); ok {
+			published = &t
+		}
+		rows = append(rows, models.FeedItem{
+			FeedID:      feedID,
+			GUID:        itemGUID(item),
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			Content:     item.Content,
+			PublishedAt: published,
+			FetchedAt:   now,
+		})
+	}
+	if len(rows) == 0 {
+		return 0
+	}
+
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+	if result.Error != nil {
+		fmt.Printf("Failed to store feed items: %v\n", result.Error)
+		return 0
+	}
+	return int(result.RowsAffected)
+}
+
+// itemGUID falls back to the link, then a title hash, for feeds without GUIDs.
+func itemGUID(item *gofeed.Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	sum := sha1.Sum([]byte(item.Title))
+	return "sha1:" + hex.EncodeToString(sum[:])
+}
+
+// recordFeedHealth updates success/failure counters and the latency average,
+// disabling the feed once it has failed RSS_MAX_FAILURES times in a row.
+func recordFeedHealth(feed *models.Feed, latency time.Duration, fetchErr error) {
+	now := time.Now()
+	ms := float64(latency.Milliseconds())
+	if feed.AvgLatencyMs == 0 {
+		feed.AvgLatencyMs = ms
+	} else {
+		feed.AvgLatencyMs = 0.8*feed.AvgLatencyMs + 0.2*ms
+	}
+	feed.LastFetchedAt = &now
+
+	if fetchErr == nil {
+		feed.LastSuccessAt = &now
+		feed.ConsecutiveFailures = 0
+		feed.LastError = ""
+	} else {
+		feed.ConsecutiveFailures++
+		feed.LastError = fetchErr.Error()
+		if feed.ConsecutiveFailures >= envInt("RSS_MAX_FAILURES", 5) {
+			feed.Disabled = true
+			feed.DisabledAt = &now
+			fmt.Printf("Disabling feed %s after %d consecutive failures\n", feed.URL, feed.ConsecutiveFailures)
+		}
+	}
+
+	db.DB.Model(feed).Select("avg_latency_ms", "last_fetched_at", "last_success_at", "consecutive_failures",
+		"last_error", "disabled", "disabled_at").Updates(feed)
+}
+
+// ListFeeds returns every registered feed with its health, failing and
+// disabled feeds first.
+func ListFeeds() ([]models.Feed, error) {
+	var feeds []models.Feed
+	err := db.DB.Order("disabled desc, consecutive_failures desc, id").Find(&feeds).Error
+	return feeds, err
+}
+
+// EnableFeed re-enables a feed and clears its failure streak.
+func EnableFeed(id uint) (*models.Feed, error) {
+	var feed models.Feed
+	if err := db.DB.First(&feed, id).Error; err != nil {
+		return nil, err
+	}
+	feed.Disabled, feed.DisabledAt, feed.ConsecutiveFailures = false, nil, 0
+	err := db.DB.Model(&feed).Select("Disabled", "DisabledAt", "ConsecutiveFailures").Updates(&feed).Error
+	return &feed, err
+}
+
+// StoredFeedNews returns archived feed items published in [from, to) that
+// concern query: items from the query's own templated feeds, plus items from
+// general feeds that mention the party's name or an alias as a whole word.
+func StoredFeedNews(query string, from, to time.Time) ([]NewsItem, error) {
+	var feedURLs []string
+	for _, t := range ResolveTargets(TargetRSS, partyIDByName(query), "") {
+		if strings.Contains(t.Value, "{query}") {
+			feedURLs = append(feedURLs, expandTarget(t.Value, query))
+		}
+	}
+
+	type row struct {
+		models.FeedItem
+		Source   string
+		TargetID uint
+	}
+	var rows []row
+	pattern := wordPatternSQL(partyNames(query))
+	err := db.DB.Table("feed_items").
+		Select("feed_items.*, feeds.source, feeds.target_id").
+		Joins("JOIN feeds ON feeds.id = feed_items.feed_id").
+		Where("feed_items.published_at >= ? AND feed_items.published_at < ?", from, to).
+		Where("feeds.url IN ? OR feed_items.title ~* ? OR feed_items.description ~* ? OR feed_items.body ~* ?",
+			append(feedURLs, ""), pattern, pattern, pattern).
+		Order("feed_items.published_at").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var items []NewsItem
+	for _, r := range rows {
+		items = append(items, NewsItem{
+			Title:       r.Title,
+			Link:        r.Link,
+			Description: r.Description,
+			Content:     r.Content,
+			Body:        r.Body,
+			PublishedAt: *r.PublishedAt,
+			Source:      r.Source,
+			TargetID:    r.TargetID,
+			FeedItemID:  r.ID,
+		})
+	}
+	return items, nil
+}
+
+// wordPatternSQL builds a Postgres regex matching any of names as a whole
+// word, so "DMK" doesn't match inside "AIADMK".
+func wordPatternSQL(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = regexp.QuoteMeta(n)
+	}
+	return `\m(?:` + strings.Join(quoted, "|") + `)\M`
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+const (
+	TargetSubreddit  = "subreddit"
+	TargetRSS        = "rss"
+	TargetNewsDomain = "news_domain"
+
+	ScopeGlobal = "global"
+	ScopeParty  = "party"
+	ScopeRegion = "region"
+)
+
+// defaultTargets are the sources the services shipped with. They seed an
+// empty source_targets table and are used directly when there is no database.
+var defaultTargets = []models.SourceTarget{
+	{Kind: TargetSubreddit, Value: "TamilNadu", Label: "r/TamilNadu"},
+	{Kind: TargetSubreddit, Value: "Chennai", Label: "r/Chennai"},
+	{Kind: TargetSubreddit, Value: "India", Label: "r/India"},
+	{Kind: TargetRSS, Value: "https://news.google.com/rss/search?q={query}&hl=ta&gl=IN&ceid=IN:ta", Label: "Google News"},
+	{Kind: TargetRSS, Value: "https://feeds.feedburner.com/dinamalar/Front_page_news", Label: "Dinamalar"},
+	{Kind: TargetRSS, Value: "https://tamil.hindustantimes.com/rss/tamilnadu", Label: "Hindustan Times Tamil"},
+	{Kind: TargetRSS, Value: "https://tamil.news18.com/commonfeeds/v1/tam/rss/tamil-nadu.xml", Label: "News18 Tamil Nadu"},
+}
+
+// EnsureDefaultTargets seeds source_targets on first start.
+func EnsureDefaultTargets() {
+	if db.DB == nil {
+		return
+	}
+	var count int64
+	if err := db.DB.Model(&models.SourceTarget{}).Count(&count).Error; err != nil || count > 0 {
+		return
+	}
+
+	seed := make([]models.SourceTarget, len(defaultTargets))
+	for i, t := range defaultTargets {
+		t.Scope = ScopeGlobal
+		t.Active = true
+		seed[i] = t
+	}
+	if err := db.DB.Create(&seed).Error; err != nil {
+		fmt.Printf("Failed to seed default source targets: %v\n", err)
+	}
+}
+
+// partyIDByName resolves name like ResolveParty, so aliases work too. It
+// returns 0 when the party is unknown or there is no database.
+func partyIDByName(name string) uint {
+	party, err := ResolveParty(name)
+	if err != nil {
+		return 0
+	}
+	return party.ID
+}
+
+// ResolveTargets returns the active targets of a kind that apply to a party:
+// global ones, the party's own, and regional ones. An empty region means a
+// statewide run, which includes every regional target.
+func ResolveTargets(kind string, partyID uint, region string) []models.SourceTarget {
+	if db.DB == nil {
+		var targets []models.SourceTarget
+		for _, t := range defaultTargets {
+			if t.Kind == kind {
+				targets = append(targets, t)
+			}
+		}
+		return targets
+	}
+
+	q := db.DB.Where("kind = ? AND active = ?", kind, true)
+	if region == "" {
+		q = q.Where("scope IN ? OR (scope = ? AND party_id = ?)", []string{ScopeGlobal, ScopeRegion}, ScopeParty, partyID)
+	} else {
+		q = q.Where("scope = ? OR (scope = ? AND party_id = ?) OR (scope = ? AND region = ?)",
+			ScopeGlobal, ScopeParty, partyID, ScopeRegion, region)
+	}
+
+	var targets []models.SourceTarget
+	if err := q.Order("id").Find(&targets).Error; err != nil {
+		fmt.Printf("Failed to load %s targets: %v\n", kind, err)
+	}
+	return targets
+}
+
+// expandTarget fills the {query} placeholder of templated feed URLs.
+func expandTarget(value, query string) string {
+	return strings.ReplaceAll(value, "{query}", url.QueryEscape(query))
+}
@@ -1,19 +1,58 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
 	"time"
 )
 
 type YouTubeComment struct {
-	Text        string    `json:"textDisplay"`
-	Author      string    `json:"authorDisplayName"`
-	PublishedAt time.Time `json:"publishedAt"`
-	Likes       int       `json:"likeCount"`
+	CommentID       string    `json:"id"`
+	ParentID        string    `json:"parentId,omitempty"` // Set on replies
+	IsReply         bool      `json:"isReply"`
+	Text            string    `json:"textDisplay"`
+	Author          string    `json:"authorDisplayName"`
+	AuthorChannelID string    `json:"authorChannelId"`
+	AuthorCreatedAt time.Time `json:"authorCreatedAt"` // Zero if the channel lookup was skipped
+	VideoID         string    `json:"videoId"`
+	VideoTitle      string    `json:"videoTitle"`
+	ChannelID       string    `json:"channelId"`
+	ChannelTitle    string    `json:"channelTitle"`
+	Origin          string    `json:"origin"` // OriginWatchlist or OriginSearch
+	PublishedAt     time.Time `json:"publishedAt"`
+	Likes           int       `json:"likeCount"`
+
+	// Set by DetectInauthentic
+	Suspicion float64  `json:"suspicion,omitempty"`
+	Flags     []string `json:"flags,omitempty"`
+}
+
+// Where a comment's video was discovered
+const (
+	OriginWatchlist = "watchlist"
+	OriginSearch    = "search"
+)
+
+// Quota cost per call, see https://developers.google.com/youtube/v3/determine_quota_cost
+const (
+	costSearch         = 100
+	costCommentThreads = 1
+	costComments       = 1
+	costChannels       = 1
+	costPlaylistItems  = 1
+)
+
+type youtubeVideo struct {
+	ID           string
+	Title        string
+	ChannelID    string
+	ChannelTitle string
+	Origin       string
 }
 
 type searchResponse struct {
@@ -21,100 +60,428 @@ type searchResponse struct {
 		Id struct {
 			VideoId string `json:"videoId"`
 		} `json:"id"`
+		Snippet struct {
+			Title        string `json:"title"`
+			ChannelId    string `json:"channelId"`
+			ChannelTitle string `json:"channelTitle"`
+		} `json:"snippet"`
 	} `json:"items"`
 }
 
+type commentSnippet struct {
+	TextDisplay       string `json:"textDisplay"`
+	AuthorDisplayName string `json:"authorDisplayName"`
+	AuthorChannelId   struct {
+		Value string `json:"value"`
+	} `json:"authorChannelId"`
+	ParentId    string `json:"parentId"`
+	PublishedAt string `json:"publishedAt"`
+	LikeCount   int    `json:"likeCount"`
+}
+
+type commentResource struct {
+	Id      string         `json:"id"`
+	Snippet commentSnippet `json:"snippet"`
+}
+
 type commentThreadResponse struct {
-	Items []struct {
+	NextPageToken string `json:"nextPageToken"`
+	Items         []struct {
+		Id      string `json:"id"`
 		Snippet struct {
-			TopLevelComment struct {
-				Snippet struct {
-					TextDisplay       string `json:"textDisplay"`
-					AuthorDisplayName string `json:"authorDisplayName"`
-					PublishedAt       string `json:"publishedAt"`
-					LikeCount         int    `json:"likeCount"`
-				} `json:"snippet"`
-			} `json:"topLevelComment"`
+			TopLevelComment commentResource `json:"topLevelComment"`
+			TotalReplyCount int             `json:"totalReplyCount"`
 		} `json:"snippet"`
+		Replies struct {
+			Comments []commentResource `json:"comments"`
+		} `json:"replies"`
 	} `json:"items"`
 }
 
-func FetchYouTubeComments(query string) ([]YouTubeComment, error) {
-	apiKey := os.Getenv("YOUTUBE_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("YOUTUBE_API_KEY is not set")
+type commentListResponse struct {
+	NextPageToken string            `json:"nextPageToken"`
+	Items         []commentResource `json:"items"`
+}
+
+// youtubeConfig controls how widely a single run samples. Defaults keep a run
+// at roughly one search plus a few dozen comment pages.
+type youtubeConfig struct {
+	MaxVideos           int  // Search-discovered videos to sample comments from
+	WatchlistVideos     int  // Watchlist videos to sample comments from
+	MaxCommentsPerVideo int  // Top-level comments plus replies per video
+	IncludeReplies      bool // Fetch reply threads, not just top-level comments
+	SearchEnabled       bool // search.list costs 100 units; watchlists alone may be enough
+	RunBudget           int  // Quota units a single run may spend
+}
+
+func loadYouTubeConfig() youtubeConfig {
+	return youtubeConfig{
+		MaxVideos:           envInt("YOUTUBE_MAX_VIDEOS", 5),
+		WatchlistVideos:     envInt("YOUTUBE_WATCHLIST_VIDEOS", 5),
+		MaxCommentsPerVideo: envInt("YOUTUBE_MAX_COMMENTS_PER_VIDEO", 100),
+		IncludeReplies:      envBool("YOUTUBE_INCLUDE_REPLIES", false),
+		SearchEnabled:       envBool("YOUTUBE_SEARCH_ENABLED", true),
+		RunBudget:           envInt("YOUTUBE_RUN_BUDGET", 300),
 	}
+}
 
-	// 1. Search for videos (Part 1 of Data Source)
-	// Searching for "Party Name speech" or similar as per plan
-	searchQuery := fmt.Sprintf("%s speech", query)
-	// Increase maxResults to try multiple videos
-	searchURL := fmt.Sprintf("https://www.googleapis.com/youtube/v3/search?part=snippet&type=video&q=%s&key=%s&maxResults=5&order=date",
-		url.QueryEscape(searchQuery), apiKey)
+// youtubeClient wraps the Data API with per-run quota accounting.
+type youtubeClient struct {
+	apiKey string
+	cfg    youtubeConfig
+	spent  int
+}
+
+var (
+	errYouTubeBudget = fmt.Errorf("youtube run budget exhausted")
+	errYouTubeQuota  = fmt.Errorf("youtube daily quota exhausted")
+)
+
+func (yt *youtubeClient) canAfford(cost int) bool {
+	return yt.spent+cost <= yt.cfg.RunBudget
+}
+
+// get calls a Data API endpoint and decodes the JSON response into out.
+// Every call is charged to both the run budget and the daily YouTubeQuota.
+func (yt *youtubeClient) get(ctx context.Context, endpoint string, params url.Values, cost int, out interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !yt.canAfford(cost) {
+		return errYouTubeBudget
+	}
+	if !YouTubeQuota.CanAfford(cost) {
+		return errYouTubeQuota
+	}
+	params.Set("key", yt.apiKey)
+	reqURL := fmt.Sprintf("https://www.googleapis.com/youtube/v3/%s?%s", endpoint, params.Encode())
 
-	resp, err := http.Get(searchURL)
+	// Quota is charged whether or not the call succeeds
+	yt.spent += cost
+	YouTubeQuota.Spend(endpoint, cost)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := newHTTPClient(10 * time.Second).Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("youtube search failed: %w", err)
+		return fmt.Errorf("youtube %s failed: %w", endpoint, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("youtube search failed with status: %d", resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if resp.StatusCode == 403 && strings.Contains(string(body), "quotaExceeded") {
+			YouTubeQuota.MarkExhausted()
+			return errYouTubeQuota
+		}
+		return fmt.Errorf("youtube %s failed with status: %d", endpoint, resp.StatusCode)
 	}
 
-	var searchRes searchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchRes); err != nil {
-		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", endpoint, err)
 	}
+	return nil
+}
 
-	if len(searchRes.Items) == 0 {
-		fmt.Printf("YouTube Search returned 0 videos for query: %s\n", query)
-		return []YouTubeComment{}, nil // No videos found
+// EstimateYouTubeRunCost is the most quota units a FetchYouTubeComments run
+// can spend with the current configuration and watchlist. Runs stop at the
+// run budget, so that caps the estimate too.
+func EstimateYouTubeRunCost() int {
+	cfg := loadYouTubeConfig()
+	perVideo := (cfg.MaxCommentsPerVideo + 99) / 100 * costCommentThreads
+	if cfg.IncludeReplies {
+		// Each thread with more replies than commentThreads embeds takes at
+		// least one comments page, and adds at least two comments
+		perVideo += cfg.MaxCommentsPerVideo / 2 * costComments
 	}
+	cost := (cfg.WatchlistVideos + cfg.MaxVideos) * perVideo
 
-	// 2. Iterate through videos to find one with comments
-	for _, item := range searchRes.Items {
-		videoId := item.Id.VideoId
+	// One playlistItems call per watched channel, plus a channels lookup per
+	// 50 channels whose uploads playlist isn't cached yet
+	channels, unresolved := watchlistSize()
+	cost += channels * costPlaylistItems
+	cost += (unresolved + 49) / 50 * costChannels
 
-		commentsURL := fmt.Sprintf("https://www.googleapis.com/youtube/v3/commentThreads?part=snippet&videoId=%s&key=%s&maxResults=50",
-			videoId, apiKey)
+	cost += (maxAuthorLookups + 49) / 50 * costChannels
+	if cfg.SearchEnabled {
+		// Search over-fetches, and a candidate with comments disabled still
+		// costs a commentThreads call
+		cost += costSearch + cfg.MaxVideos*costCommentThreads
+	}
+	return min(cost, cfg.RunBudget)
+}
+
+// CanAffordYouTubeRun lets callers such as schedulers check the remaining
+// daily quota before starting a run.
+func CanAffordYouTubeRun() bool {
+	return YouTubeQuota.CanAfford(EstimateYouTubeRunCost())
+}
 
-		cResp, err := http.Get(commentsURL)
+func FetchYouTubeComments(ctx context.Context, query string) ([]YouTubeComment, error) {
+	apiKey := apiKeyFromEnv("YOUTUBE_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: YOUTUBE_API_KEY is not set", errSkipped)
+	}
+
+	yt := &youtubeClient{apiKey: apiKey, cfg: loadYouTubeConfig()}
+	defer YouTubeQuota.Flush()
+	// Never plan to spend more than what is left for the day
+	yt.cfg.RunBudget = min(yt.cfg.RunBudget, YouTubeQuota.Remaining())
+	if yt.cfg.RunBudget <= 0 {
+		return nil, errYouTubeQuota
+	}
+
+	// Watchlist uploads come first: they are cheap and on-topic
+	watched := yt.watchlistVideos(ctx, query)
+	comments, sampled := yt.sampleVideos(ctx, watched, yt.cfg.WatchlistVideos, nil)
+
+	if yt.cfg.SearchEnabled {
+		found, err := yt.searchVideos(ctx, query)
+		if err != nil && len(comments) == 0 {
+			return nil, err
+		}
 		if err != nil {
-			fmt.Printf("Failed to fetch comments for video %s: %v\n", videoId, err)
+			fmt.Printf("YouTube search failed, using watchlist comments only: %v\n", err)
+		}
+		if len(found) == 0 && err == nil {
+			fmt.Printf("YouTube Search returned 0 videos for query: %s\n", query)
+		}
+
+		seen := make(map[string]bool)
+		for _, v := range watched {
+			seen[v.ID] = true
+		}
+		searchComments, n := yt.sampleVideos(ctx, found, yt.cfg.MaxVideos, seen)
+		comments = append(comments, searchComments...)
+		sampled += n
+	}
+
+	yt.lookupAuthors(ctx, comments)
+
+	fmt.Printf("Found %d comments across %d videos (%d quota units)\n", len(comments), sampled, yt.spent)
+	if comments == nil {
+		comments = []YouTubeComment{}
+	}
+	// Out of time: keep what we have but say the sample is incomplete
+	return comments, ctx.Err()
+}
+
+// sampleVideos collects comments from up to limit videos that have any,
+// skipping IDs in skip. Spreading across videos keeps a single upload from
+// dominating the signal.
+func (yt *youtubeClient) sampleVideos(ctx context.Context, videos []youtubeVideo, limit int, skip map[string]bool) ([]YouTubeComment, int) {
+	var comments []YouTubeComment
+	sampled := 0
+	for _, v := range videos {
+		if sampled >= limit || !yt.canAfford(costCommentThreads) || ctx.Err() != nil {
+			break
+		}
+		if skip[v.ID] {
 			continue
 		}
-		defer cResp.Body.Close()
 
-		if cResp.StatusCode != 200 {
+		vc, err := yt.fetchVideoComments(ctx, v)
+		if err != nil && len(vc) == 0 {
 			// Comments likely disabled or API error
-			fmt.Printf("YouTube Comments API status %d for video %s (likely disabled)\n", cResp.StatusCode, videoId)
+			fmt.Printf("Skipping video %s: %v\n", v.ID, err)
 			continue
 		}
+		if len(vc) > 0 {
+			sampled++
+			comments = append(comments, vc...)
+		}
+	}
+	return comments, sampled
+}
 
-		var commentsRes commentThreadResponse
-		if err := json.NewDecoder(cResp.Body).Decode(&commentsRes); err != nil {
-			continue
+// searchVideos finds recent uploads for the query. It over-fetches so videos
+// with comments disabled can be skipped.
+func (yt *youtubeClient) searchVideos(ctx context.Context, query string) ([]youtubeVideo, error) {
+	// Searching for "Party Name speech" or similar as per plan
+	params := url.Values{}
+	params.Set("part", "snippet")
+	params.Set("type", "video")
+	params.Set("q", fmt.Sprintf("%s speech", query))
+	params.Set("order", "date")
+	params.Set("maxResults", fmt.Sprint(min(yt.cfg.MaxVideos*2, 50)))
+
+	var searchRes searchResponse
+	if err := yt.get(ctx, "search", params, costSearch, &searchRes); err != nil {
+		return nil, err
+	}
+
+	var videos []youtubeVideo
+	for _, item := range searchRes.Items {
+		videos = append(videos, youtubeVideo{
+			ID:           item.Id.VideoId,
+			Title:        item.Snippet.Title,
+			ChannelID:    item.Snippet.ChannelId,
+			ChannelTitle: item.Snippet.ChannelTitle,
+			Origin:       OriginSearch,
+		})
+	}
+	return videos, nil
+}
+
+// fetchVideoComments pages through a video's comment threads until the
+// per-video cap or the run budget is reached. Whatever was collected before an
+// error is returned alongside it.
+func (yt *youtubeClient) fetchVideoComments(ctx context.Context, v youtubeVideo) ([]YouTubeComment, error) {
+	var comments []YouTubeComment
+	pageToken := ""
+
+	for len(comments) < yt.cfg.MaxCommentsPerVideo {
+		part := "snippet"
+		if yt.cfg.IncludeReplies {
+			part = "snippet,replies"
+		}
+		params := url.Values{}
+		params.Set("part", part)
+		params.Set("videoId", v.ID)
+		params.Set("order", "relevance")
+		params.Set("maxResults", "100")
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var res commentThreadResponse
+		if err := yt.get(ctx, "commentThreads", params, costCommentThreads, &res); err != nil {
+			return comments, err
+		}
+
+		for _, thread := range res.Items {
+			if len(comments) >= yt.cfg.MaxCommentsPerVideo {
+				break
+			}
+			comments = append(comments, v.comment(thread.Snippet.TopLevelComment, false))
+
+			if !yt.cfg.IncludeReplies || thread.Snippet.TotalReplyCount == 0 {
+				continue
+			}
+			replies := thread.Replies.Comments
+			if thread.Snippet.TotalReplyCount > len(replies) {
+				// commentThreads only embeds a handful of replies
+				if full, err := yt.fetchReplies(ctx, thread.Id, yt.cfg.MaxCommentsPerVideo-len(comments)); err == nil {
+					replies = full
+				}
+			}
+			for _, r := range replies {
+				if len(comments) >= yt.cfg.MaxCommentsPerVideo {
+					break
+				}
+				comments = append(comments, v.comment(r, true))
+			}
+		}
+
+		if res.NextPageToken == "" {
+			break
+		}
+		pageToken = res.NextPageToken
+	}
+
+	return comments, nil
+}
+
+// fetchReplies pages through all replies to a top-level comment, up to limit.
+func (yt *youtubeClient) fetchReplies(ctx context.Context, parentID string, limit int) ([]commentResource, error) {
+	var replies []commentResource
+	pageToken := ""
+
+	for len(replies) < limit {
+		params := url.Values{}
+		params.Set("part", "snippet")
+		params.Set("parentId", parentID)
+		params.Set("maxResults", "100")
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		var res commentListResponse
+		if err := yt.get(ctx, "comments", params, costComments, &res); err != nil {
+			return replies, err
+		}
+		replies = append(replies, res.Items...)
+
+		if res.NextPageToken == "" {
+			break
 		}
+		pageToken = res.NextPageToken
+	}
 
-		if len(commentsRes.Items) > 0 {
-			// Found comments! Parse and return.
-			var comments []YouTubeComment
-			for _, cItem := range commentsRes.Items {
-				snippet := cItem.Snippet.TopLevelComment.Snippet
-				t, _ := time.Parse(time.RFC3339, snippet.PublishedAt)
-				comments = append(comments, YouTubeComment{
-					Text:        snippet.TextDisplay,
-					Author:      snippet.AuthorDisplayName,
-					PublishedAt: t,
-					Likes:       snippet.LikeCount,
-				})
+	if len(replies) > limit {
+		replies = replies[:limit]
+	}
+	return replies, nil
+}
+
+func (v youtubeVideo) comment(c commentResource, isReply bool) YouTubeComment {
+	t, _ := time.Parse(time.RFC3339, c.Snippet.PublishedAt)
+	return YouTubeComment{
+		CommentID:       c.Id,
+		ParentID:        c.Snippet.ParentId,
+		IsReply:         isReply,
+		Text:            c.Snippet.TextDisplay,
+		Author:          c.Snippet.AuthorDisplayName,
+		AuthorChannelID: c.Snippet.AuthorChannelId.Value,
+		VideoID:         v.ID,
+		VideoTitle:      v.Title,
+		ChannelID:       v.ChannelID,
+		ChannelTitle:    v.ChannelTitle,
+		Origin:          v.Origin,
+		PublishedAt:     t,
+		Likes:           c.Snippet.LikeCount,
+	}
+}
+
+type channelListResponse struct {
+	Items []struct {
+		Id      string `json:"id"`
+		Snippet struct {
+			PublishedAt string `json:"publishedAt"`
+		} `json:"snippet"`
+	} `json:"items"`
+}
+
+// maxAuthorLookups caps how many commenter channels we look up per run.
+// channels.list takes up to 50 IDs per call at 1 quota unit.
+const maxAuthorLookups = 100
+
+// lookupAuthors fills AuthorCreatedAt so DetectInauthentic can spot freshly
+// created accounts. Failures are logged and leave the field zero.
+func (yt *youtubeClient) lookupAuthors(ctx context.Context, comments []YouTubeComment) {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, c := range comments {
+		if c.AuthorChannelID != "" && !seen[c.AuthorChannelID] && len(ids) < maxAuthorLookups {
+			seen[c.AuthorChannelID] = true
+			ids = append(ids, c.AuthorChannelID)
+		}
+	}
+
+	created := make(map[string]time.Time)
+	for start := 0; start < len(ids); start += 50 {
+		end := min(start+50, len(ids))
+
+		params := url.Values{}
+		params.Set("part", "snippet")
+		params.Set("id", strings.Join(ids[start:end], ","))
+		params.Set("maxResults", "50")
+
+		var channelsRes channelListResponse
+		if err := yt.get(ctx, "channels", params, costChannels, &channelsRes); err != nil {
+			fmt.Printf("YouTube channel lookup failed: %v\n", err)
+			break
+		}
+
+		for _, item := range channelsRes.Items {
+			if t, err := time.Parse(time.RFC3339, item.Snippet.PublishedAt); err == nil {
+				created[item.Id] = t
 			}
-			fmt.Printf("Found %d comments on video %s\n", len(comments), videoId)
-			return comments, nil
 		}
 	}
 
-	fmt.Println("No comments found on any of the recent videos.")
-	return []YouTubeComment{}, nil
+	for i := range comments {
+		comments[i].AuthorCreatedAt = created[comments[i].AuthorChannelID]
+	}
 }
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"election-pulse-backend/db"
+	"election-pulse-backend/models"
+)
+
+type channelContentResponse struct {
+	Items []struct {
+		Id             string `json:"id"`
+		ContentDetails struct {
+			RelatedPlaylists struct {
+				Uploads string `json:"uploads"`
+			} `json:"relatedPlaylists"`
+		} `json:"contentDetails"`
+	} `json:"items"`
+}
+
+type playlistItemsResponse struct {
+	Items []struct {
+		Snippet struct {
+			Title        string `json:"title"`
+			ChannelId    string `json:"channelId"`
+			ChannelTitle string `json:"channelTitle"`
+			ResourceId   struct {
+				VideoId string `json:"videoId"`
+			} `json:"resourceId"`
+		} `json:"snippet"`
+	} `json:"items"`
+}
+
+// loadWatchedChannels returns the active channels for a party plus the
+// party-agnostic news channels.
+func loadWatchedChannels(partyName string) []models.WatchedChannel {
+	if db.DB == nil {
+		return nil
+	}
+
+	q := db.DB.Where("active = ?", true)
+	if partyID := partyIDByName(partyName); partyID != 0 {
+		q = q.Where("party_id = ? OR party_id IS NULL", partyID)
+	} else {
+		q = q.Where("party_id IS NULL")
+	}
+
+	var channels []models.WatchedChannel
+	if err := q.Find(&channels).Error; err != nil {
+		fmt.Printf("Failed to load YouTube watchlist: %v\n", err)
+		return nil
+	}
+	return channels
+}
+
+// watchlistSize counts the active watched channels, and those without a
+// cached uploads playlist. A run only reads a party's own and the news
+// channels, so this is an upper bound.
+func watchlistSize() (channels, unresolved int) {
+	if db.DB == nil {
+		return 0, 0
+	}
+	var total, missing int64
+	db.DB.Model(&models.WatchedChannel{}).Where("active = ?", true).Count(&total)
+	db.DB.Model(&models.WatchedChannel{}).Where("active = ? AND (uploads_playlist_id IS NULL OR uploads_playlist_id = '')", true).Count(&missing)
+	return int(total), int(missing)
+}
+
+// watchlistVideos lists the latest uploads of every watched channel through
+// its uploads playlist (1 unit per channel instead of 100 for a search).
+// Uploads from shared news channels are kept only when the title mentions
+// the party's name or one of its aliases as a whole word.
+func (yt *youtubeClient) watchlistVideos(ctx context.Context, query string) []youtubeVideo {
+	channels := loadWatchedChannels(query)
+	if len(channels) == 0 {
+		return nil
+	}
+	yt.resolveUploadsPlaylists(ctx, channels)
+	mentions := namesPattern(partyNames(query))
+
+	var videos []youtubeVideo
+	for _, ch := range channels {
+		if ch.UploadsPlaylistID == "" {
+			continue
+		}
+
+		filter := ch.PartyID == nil
+		maxResults := yt.cfg.WatchlistVideos
+		if filter {
+			// Scan deeper since most news uploads are about something else
+			maxResults = 50
+		}
+
+		params := url.Values{}
+		params.Set("part", "snippet")
+		params.Set("playlistId", ch.UploadsPlaylistID)
+		params.Set("maxResults", fmt.Sprint(min(maxResults, 50)))
+
+		var res playlistItemsResponse
+		if err := yt.get(ctx, "playlistItems", params, costPlaylistItems, &res); err != nil {
+			fmt.Printf("Failed to list uploads for channel %s: %v\n", ch.ChannelID, err)
+			continue
+		}
+
+		for _, item := range res.Items {
+			if filter && !mentions.MatchString(item.Snippet.Title) {
+				continue
+			}
+			videos = append(videos, youtubeVideo{
+				ID:           item.Snippet.ResourceId.VideoId,
+				Title:        item.Snippet.Title,
+				ChannelID:    item.Snippet.ChannelId,
+				ChannelTitle: item.Snippet.ChannelTitle,
+				Origin:       OriginWatchlist,
+			})
+		}
+	}
+
+	fmt.Printf("YouTube watchlist: %d candidate videos from %d channels\n", len(videos), len(channels))
+	return videos
+}
+
+// resolveUploadsPlaylists fills and caches UploadsPlaylistID for channels
+// that don't have one yet.
+func (yt *youtubeClient) resolveUploadsPlaylists(ctx context.Context, channels []models.WatchedChannel) {
+	byID := make(map[string][]*models.WatchedChannel)
+	var ids []string
+	for i := range channels {
+		ch := &channels[i]
+		if ch.UploadsPlaylistID != "" {
+			continue
+		}
+		if byID[ch.ChannelID] == nil {
+			ids = append(ids, ch.ChannelID)
+		}
+		byID[ch.ChannelID] = append(byID[ch.ChannelID], ch)
+	}
+
+	for start := 0; start < len(ids); start += 50 {
+		end := min(start+50, len(ids))
+
+		params := url.Values{}
+		params.Set("part", "contentDetails")
+		params.Set("id", strings.Join(ids[start:end], ","))
+		params.Set("maxResults", "50")
+
+		var res channelContentResponse
+		if err := yt.get(ctx, "channels", params, costChannels, &res); err != nil {
+			fmt.Printf("Failed to resolve uploads playlists: %v\n", err)
+			return
+		}
+
+		for _, item := range res.Items {
+			for _, ch := range byID[item.Id] {
+				ch.UploadsPlaylistID = item.ContentDetails.RelatedPlaylists.Uploads
+				if db.DB != nil && ch.UploadsPlaylistID != "" {
+					db.DB.Model(ch).Update("uploads_playlist_id", ch.UploadsPlaylistID)
+				}
+			}
+		}
+	}
+}